@@ -0,0 +1,260 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package greenrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// StdlibDefaults returns the package's own greenrun functions for types of the
+// standard library, which every GreenRunner uses unless DisableDefault is
+// called. They can be passed to Funcs, e.g. to give them precedence over the
+// greenrun.Interface implementations of types embedding them. They make:
+//
+//   - time.Time: an instant in UTC within TimeRange, by default within about
+//     1000 years after the Unix epoch;
+//   - time.Duration: a duration within DurationRange, by default between 0
+//     and 24 hours;
+//   - net.IP and netip.Addr: a valid IPv4 or IPv6 address;
+//   - net.IPNet and netip.Prefix: a valid IPv4 or IPv6 network, with the
+//     bits outside of its mask cleared;
+//   - net.HardwareAddr: a 6 byte (EUI-48) hardware address;
+//   - url.URL: a URL that survives a round trip through url.Parse;
+//   - json.RawMessage: a small, valid JSON document, or nil;
+//   - big.Int: an integer of up to 128 bits;
+//   - big.Float: a number with a magnitude of up to around 1e18.
+func StdlibDefaults() []interface{} {
+	return []interface{}{
+		greenrunTime,
+		greenrunDuration,
+		greenrunIP,
+		greenrunIPNet,
+		greenrunHardwareAddr,
+		greenrunAddr,
+		greenrunPrefix,
+		greenrunURL,
+		greenrunRawMessage,
+		greenrunBigInt,
+		greenrunBigFloat,
+	}
+}
+
+// UseStdlibDefaults makes f use all of the functions returned by
+// StdlibDefaults again, undoing DisableDefault. Like the package's other
+// defaults, they are only used for types without custom functions.
+func (f *GreenRunner) UseStdlibDefaults() *GreenRunner {
+	for t, fn := range stdlibDefaultFuncs() {
+		f.defaultGreenRunFuncs[t] = fn
+	}
+	return f
+}
+
+// stdlibDefaultFuncs returns StdlibDefaults as a greenrunFuncMap.
+func stdlibDefaultFuncs() greenrunFuncMap {
+	m := greenrunFuncMap{}
+	for _, fn := range StdlibDefaults() {
+		v := reflect.ValueOf(fn)
+		m[v.Type().In(0)] = v
+	}
+	return m
+}
+
+// greenrunIP makes a valid IPv4 or IPv6 address, with equal probability.
+func greenrunIP(ip *net.IP, c Continue) {
+	if c.RandBool() {
+		*ip = net.IP(c.RandBytes(net.IPv4len))
+		return
+	}
+	*ip = net.IP(c.RandBytes(net.IPv6len))
+}
+
+// greenrunIPNet makes a valid IPv4 or IPv6 network, in the form returned by
+// net.ParseCIDR: the address has all bits outside of the mask cleared.
+func greenrunIPNet(n *net.IPNet, c Continue) {
+	var ip net.IP
+	greenrunIP(&ip, c)
+	bits := 8 * len(ip)
+	n.Mask = net.CIDRMask(c.Intn(bits+1), bits)
+	n.IP = ip.Mask(n.Mask)
+}
+
+// greenrunAddr makes a valid IPv4 or IPv6 address, with equal probability.
+func greenrunAddr(a *netip.Addr, c Continue) {
+	if c.RandBool() {
+		var b [4]byte
+		c.Read(b[:])
+		*a = netip.AddrFrom4(b)
+		return
+	}
+	var b [16]byte
+	c.Read(b[:])
+	*a = netip.AddrFrom16(b)
+}
+
+// greenrunPrefix makes a valid IPv4 or IPv6 prefix, in the form returned by
+// netip.ParsePrefix for a network: the address has all bits outside of the
+// prefix cleared.
+func greenrunPrefix(p *netip.Prefix, c Continue) {
+	var a netip.Addr
+	greenrunAddr(&a, c)
+	*p = netip.PrefixFrom(a, c.Intn(a.BitLen()+1)).Masked()
+}
+
+// greenrunHardwareAddr makes a 6 byte (EUI-48) hardware address, the most
+// common kind.
+func greenrunHardwareAddr(addr *net.HardwareAddr, c Continue) {
+	*addr = net.HardwareAddr(c.RandBytes(6))
+}
+
+// greenrunURL makes a URL that survives a round trip through its String
+// method and url.Parse: a scheme, a host with an optional port, and an
+// optional path and query, all made of URL-safe characters.
+func greenrunURL(u *url.URL, c Continue) {
+	*u = url.URL{}
+	u.Scheme = []string{"http", "https", "ftp", "ws"}[c.Intn(4)]
+
+	labels := make([]string, 1+c.Intn(3))
+	for i := range labels {
+		labels[i] = randLabel(c, 1, 10)
+	}
+	labels = append(labels, []string{"com", "org", "net", "io"}[c.Intn(4)])
+	u.Host = strings.Join(labels, ".")
+	if c.RandBool() {
+		u.Host += ":" + strconv.Itoa(1+c.Intn(65535))
+	}
+
+	if c.RandBool() {
+		for i, n := 0, 1+c.Intn(3); i < n; i++ {
+			u.Path += "/" + randLabel(c, 1, 10)
+		}
+	}
+	if c.RandBool() {
+		q := url.Values{}
+		for i, n := 0, 1+c.Intn(3); i < n; i++ {
+			q.Add(randLabel(c, 1, 5), randLabel(c, 0, 10))
+		}
+		u.RawQuery = q.Encode()
+	}
+}
+
+const labelChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// randLabel makes a string of between min and max lowercase letters and
+// digits, which are safe to use anywhere in a URL or host name.
+func randLabel(c Continue, min, max int) string {
+	b := make([]byte, min+c.Intn(max-min+1))
+	for i := range b {
+		b[i] = labelChars[c.Intn(len(labelChars))]
+	}
+	return string(b)
+}
+
+// greenrunRawMessage makes a small, valid JSON document, or leaves m nil as
+// determined by NilChance.
+func greenrunRawMessage(m *json.RawMessage, c Continue) {
+	if !c.fc.greenruner.genShouldFill(reflect.Slice) {
+		c.fc.setNil(reflect.ValueOf(m).Elem(), "nil json.RawMessage")
+		return
+	}
+	b, err := json.Marshal(randJSONValue(c, 2))
+	if err != nil {
+		panic(err)
+	}
+	*m = b
+}
+
+// randJSONValue makes a random value that encodes to JSON as an object,
+// array, string, number, boolean or null. Objects and arrays are only made
+// while depth > 0, and their elements have a smaller depth.
+func randJSONValue(c Continue, depth int) interface{} {
+	kinds := 4
+	if depth > 0 {
+		kinds = 6
+	}
+	switch c.Intn(kinds) {
+	case 0:
+		return nil
+	case 1:
+		return c.RandBool()
+	case 2:
+		return c.Float64() * float64(c.Intn(1000))
+	case 3:
+		return c.RandString()
+	case 4:
+		a := make([]interface{}, c.Intn(4))
+		for i := range a {
+			a[i] = randJSONValue(c, depth-1)
+		}
+		return a
+	default:
+		o := map[string]interface{}{}
+		for i, n := 0, c.Intn(4); i < n; i++ {
+			o[c.RandString()] = randJSONValue(c, depth-1)
+		}
+		return o
+	}
+}
+
+// greenrunBigInt makes a positive or negative integer of up to 128 bits.
+func greenrunBigInt(n *big.Int, c Continue) {
+	n.SetBytes(c.RandBytes(1 + c.Intn(16)))
+	if c.RandBool() {
+		n.Neg(n)
+	}
+}
+
+// greenrunBigFloat makes a positive or negative number with a magnitude of up
+// to around 1e18.
+func greenrunBigFloat(n *big.Float, c Continue) {
+	n.SetFloat64(c.NormFloat64() * math.Pow(10, float64(c.Intn(19))))
+}
+
+// UUIDs makes values of the types of samples, each of which must be a
+// [16]byte array type such as github.com/google/uuid.UUID, be greenruned as
+// random (version 4) RFC 4122 UUIDs, rather than as arbitrary bytes. It is
+// opt-in, and takes sample values, so that this package needn't depend on any
+// UUID package:
+//
+//	f := greenrun.New().UUIDs(uuid.UUID{})
+func (f *GreenRunner) UUIDs(samples ...interface{}) *GreenRunner {
+	for _, sample := range samples {
+		t := reflect.TypeOf(sample)
+		if t == nil || t.Kind() != reflect.Array || t.Len() != 16 || t.Elem().Kind() != reflect.Uint8 {
+			panic(fmt.Sprintf("%v is not a [16]byte type", t))
+		}
+		fnType := reflect.FuncOf([]reflect.Type{reflect.PtrTo(t), reflect.TypeOf(Continue{})}, nil, false)
+		f.greenrunFuncs[reflect.PtrTo(t)] = reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+			c := args[1].Interface().(Continue)
+			var u [16]byte
+			c.Read(u[:])
+			u[6] = u[6]&0x0f | 0x40 // Version 4.
+			u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant.
+			reflect.Copy(args[0].Elem(), reflect.ValueOf(u[:]))
+			return nil
+		})
+	}
+	return f
+}
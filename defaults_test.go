@@ -0,0 +1,247 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package greenrun
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGreenRun_net(t *testing.T) {
+	obj := &struct {
+		IP   net.IP
+		Net  net.IPNet
+		PNet *net.IPNet
+		MAC  net.HardwareAddr
+	}{}
+
+	f := New().NilChance(0)
+	sawV4, sawV6 := false, false
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		if l := len(obj.IP); l != net.IPv4len && l != net.IPv6len {
+			t.Errorf("invalid IP length %v", l)
+		}
+		if parsed := net.ParseIP(obj.IP.String()); !parsed.Equal(obj.IP) {
+			t.Errorf("%v didn't round-trip, got %v", obj.IP, parsed)
+		}
+		if obj.IP.To4() != nil {
+			sawV4 = true
+		} else {
+			sawV6 = true
+		}
+		for _, n := range []*net.IPNet{&obj.Net, obj.PNet} {
+			_, parsed, err := net.ParseCIDR(n.String())
+			if err != nil {
+				t.Errorf("%v didn't parse: %v", n, err)
+			} else if parsed.String() != n.String() {
+				t.Errorf("%v didn't round-trip, got %v", n, parsed)
+			}
+		}
+		if len(obj.MAC) != 6 {
+			t.Errorf("invalid hardware address %v", obj.MAC)
+		}
+	}
+	if !sawV4 || !sawV6 {
+		t.Errorf("expected both IPv4 and IPv6 addresses")
+	}
+
+	// Defaults can be overridden.
+	f.Funcs(func(ip *net.IP, c Continue) { *ip = net.IPv4(127, 0, 0, 1) })
+	f.GreenRun(obj)
+	if !obj.IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("expected the custom func to be used, got %v", obj.IP)
+	}
+}
+
+func TestGreenRun_netip(t *testing.T) {
+	obj := &struct {
+		Addr   netip.Addr
+		Prefix netip.Prefix
+	}{}
+
+	f := New()
+	sawV4, sawV6 := false, false
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		if !obj.Addr.IsValid() {
+			t.Fatalf("invalid address %v", obj.Addr)
+		}
+		sawV4 = sawV4 || obj.Addr.Is4()
+		sawV6 = sawV6 || obj.Addr.Is6()
+		if parsed, err := netip.ParseAddr(obj.Addr.String()); err != nil || parsed != obj.Addr {
+			t.Errorf("%v didn't round-trip, got %v, %v", obj.Addr, parsed, err)
+		}
+		if parsed, err := netip.ParsePrefix(obj.Prefix.String()); err != nil || parsed != obj.Prefix || parsed != parsed.Masked() {
+			t.Errorf("%v isn't a valid network, got %v, %v", obj.Prefix, parsed, err)
+		}
+	}
+	if !sawV4 || !sawV6 {
+		t.Errorf("expected both IPv4 and IPv6 addresses")
+	}
+}
+
+func TestGreenRun_url(t *testing.T) {
+	obj := &struct {
+		URL  url.URL
+		PURL *url.URL
+	}{}
+
+	f := New().NilChance(0)
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		for _, u := range []*url.URL{&obj.URL, obj.PURL} {
+			parsed, err := url.Parse(u.String())
+			if err != nil {
+				t.Errorf("%v didn't parse: %v", u, err)
+			} else if !reflect.DeepEqual(u, parsed) {
+				t.Errorf("%#v didn't round-trip, got %#v", u, parsed)
+			}
+			if u.Scheme == "" || u.Host == "" {
+				t.Errorf("expected a scheme and host, got %v", u)
+			}
+		}
+	}
+}
+
+func TestGreenRun_rawMessage(t *testing.T) {
+	obj := &struct {
+		Raw json.RawMessage
+	}{}
+
+	f := New().NilChance(0)
+	for i := 0; i < 100; i++ {
+		f.GreenRun(obj)
+		if !json.Valid(obj.Raw) {
+			t.Errorf("invalid JSON: %s", obj.Raw)
+		}
+		if _, err := json.Marshal(obj); err != nil {
+			t.Errorf("unexpected error re-marshaling: %v", err)
+		}
+	}
+
+	f.NilChance(1)
+	f.GreenRun(obj)
+	if obj.Raw != nil {
+		t.Errorf("expected nil, got %s", obj.Raw)
+	}
+}
+
+func TestGreenRun_big(t *testing.T) {
+	obj := &struct {
+		I  *big.Int
+		F  *big.Float
+		VI big.Int
+	}{}
+
+	f := New().NilChance(0)
+	sawNeg, sawLarge := false, false
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		if obj.I == nil || obj.F == nil {
+			t.Fatalf("expected non-nil values, got %#v", obj)
+		}
+		if obj.I.BitLen() > 128 || obj.VI.BitLen() > 128 {
+			t.Errorf("unexpectedly large ints %v, %v", obj.I, &obj.VI)
+		}
+		if _, ok := new(big.Int).SetString(obj.I.String(), 10); !ok {
+			t.Errorf("%v didn't round-trip", obj.I)
+		}
+		if obj.I.Sign() < 0 {
+			sawNeg = true
+		}
+		if obj.I.BitLen() > 64 {
+			sawLarge = true
+		}
+		if obj.F.IsInf() {
+			t.Errorf("unexpected infinite float")
+		}
+	}
+	if !sawNeg || !sawLarge {
+		t.Errorf("expected negative and larger than 64 bit ints")
+	}
+
+	// Defaults can be overridden.
+	f.Funcs(func(n *big.Int, c Continue) { n.SetInt64(7) })
+	f.GreenRun(obj)
+	if obj.I.Int64() != 7 {
+		t.Errorf("expected the custom func to be used, got %v", obj.I)
+	}
+}
+
+func TestGreenRunner_UUIDs(t *testing.T) {
+	type UUID [16]byte
+	obj := &struct {
+		ID  UUID
+		IDs []UUID
+	}{}
+
+	f := New().NilChance(0).UUIDs(UUID{})
+	for i := 0; i < 20; i++ {
+		f.GreenRun(obj)
+		for _, u := range append(obj.IDs, obj.ID) {
+			if u[6]>>4 != 4 || u[8]>>6 != 2 {
+				t.Errorf("expected a version 4 RFC 4122 UUID, got %x", u)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a non-UUID type")
+		}
+	}()
+	f.UUIDs([8]byte{})
+}
+
+func TestStdlibDefaults(t *testing.T) {
+	seen := map[reflect.Type]bool{}
+	for _, fn := range StdlibDefaults() {
+		typ := reflect.TypeOf(fn).In(0).Elem()
+		if seen[typ] {
+			t.Errorf("expected %v to be listed once", typ)
+		}
+		seen[typ] = true
+	}
+	if !seen[reflect.TypeOf(time.Time{})] || !seen[reflect.TypeOf(url.URL{})] {
+		t.Errorf("expected time.Time and url.URL defaults, got %v", seen)
+	}
+
+	// They can be passed to Funcs.
+	var u url.URL
+	New().Funcs(StdlibDefaults()...).GreenRun(&u)
+	if _, err := url.Parse(u.String()); err != nil || u.Host == "" {
+		t.Errorf("expected a valid URL, got %q, %v", u.String(), err)
+	}
+
+	var ip net.IP
+	f := New().DisableDefault(reflect.TypeOf(net.IP{})).NilChance(0).NumElements(3, 3)
+	f.GreenRun(&ip)
+	if len(ip) != 3 {
+		t.Errorf("expected a plain byte slice once disabled, got %v", ip)
+	}
+	f.UseStdlibDefaults().GreenRun(&ip)
+	if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+		t.Errorf("expected a valid IP once restored, got %v", ip)
+	}
+}
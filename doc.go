@@ -15,4 +15,22 @@ limitations under the License.
 */
 
 // Package fuzz is a library for populating go objects with random values.
+//
+// For a given seed and configuration, a GreenRunner generates the same
+// values every time, across Go releases: it relies only on the seeded
+// generator of math/rand, whose sequence is fixed, and it draws from it in
+// an order that doesn't depend on map iteration. That makes it usable for
+// golden or snapshot tests. Changes that would alter the values generated
+// for an existing configuration are made only behind new options.
+//
+// In particular, map keys are generated one after another from the seeded
+// generator, so a map holds the same entries every time, however Go orders
+// them when ranging over it. Encoders that sort keys, such as encoding/json
+// for string, integer and encoding.TextMarshaler keys, therefore produce the
+// same output every time too.
+//
+// By default, the values of a field also depend on how much randomness was
+// consumed before it, so adding a field, or a custom function that draws
+// more or less of it, shifts the values of the fields that follow. With
+// DeterministicStreams, each field draws from a source of its own instead.
 package greenrun
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"strings"
 
 	"github.com/benchlab/gogreenrun"
 )
@@ -223,3 +224,70 @@ func ExampleEnum() {
 	}
 	// Output:
 }
+
+func ExampleContinue_RandBytes() {
+	type Key struct {
+		ID    string
+		Bytes []byte
+	}
+
+	f := greenrun.New().Funcs(
+		func(k *Key, c greenrun.Continue) {
+			c.GreenRun(&k.ID)
+			// Keys are always 32 bytes long.
+			k.Bytes = c.RandBytes(32)
+		},
+	)
+
+	var key Key
+	f.GreenRun(&key)
+	fmt.Printf("key has %v bytes.\n", len(key.Bytes))
+	// Output:
+	// key has 32 bytes.
+}
+
+func ExampleContinue_Path() {
+	type Account struct {
+		Name  string
+		Email string
+	}
+
+	f := greenrun.New().Funcs(
+		func(s *string, c greenrun.Continue) {
+			switch {
+			case strings.HasSuffix(c.Path(), ".Email"):
+				*s = "user@example.com"
+			default:
+				*s = "Alice"
+			}
+		},
+	)
+
+	var a Account
+	f.GreenRun(&a)
+	fmt.Printf("%v <%v>\n", a.Name, a.Email)
+	// Output:
+	// Alice <user@example.com>
+}
+
+func ExampleGreenRunner_Fuzz() {
+	type Pair struct {
+		Key   string
+		Value int
+	}
+
+	// Fuzz is equivalent to GreenRun, for both GreenRunner and Continue, so
+	// code written for gofuzz keeps working.
+	f := greenrun.New().Funcs(
+		func(p *Pair, c greenrun.Continue) {
+			c.Fuzz(&p.Key)
+			p.Value = len(p.Key)
+		},
+	)
+
+	var p Pair
+	f.Fuzz(&p)
+	fmt.Println(p.Value == len(p.Key))
+	// Output:
+	// true
+}
@@ -0,0 +1,33 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package greenrun
+
+// GreenRun returns a new value of type T, greenruned by f.
+func GreenRun[T any](f *GreenRunner) T {
+	var t T
+	f.GreenRun(&t)
+	return t
+}
+
+// GreenRunN returns n new values of type T, each greenruned by f.
+func GreenRunN[T any](f *GreenRunner, n int) []T {
+	ts := make([]T, n)
+	for i := range ts {
+		f.GreenRun(&ts[i])
+	}
+	return ts
+}
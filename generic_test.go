@@ -0,0 +1,42 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package greenrun
+
+import "testing"
+
+func TestGreenRun_generic(t *testing.T) {
+	type Pair struct {
+		A string
+		B *int
+	}
+
+	f := New().NilChance(0).StringLen(1, 10)
+	p := GreenRun[Pair](f)
+	if p.A == "" || p.B == nil {
+		t.Errorf("expected a filled value, got %#v", p)
+	}
+
+	ps := GreenRunN[Pair](f, 5)
+	if len(ps) != 5 {
+		t.Fatalf("expected 5 values, got %v", len(ps))
+	}
+	for i, p := range ps {
+		if p.B == nil {
+			t.Errorf("expected value %v to be filled, got %#v", i, p)
+		}
+	}
+}
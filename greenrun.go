@@ -17,10 +17,21 @@ limitations under the License.
 package greenrun
 
 import (
+	"context"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math"
 	"math/rand"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
 )
 
 // greenrunFuncMap is a map from a type to a greenrunFunc that handles that type.
@@ -30,11 +41,68 @@ type greenrunFuncMap map[reflect.Type]reflect.Value
 type GreenRunner struct {
 	greenrunFuncs        greenrunFuncMap
 	defaultGreenRunFuncs greenrunFuncMap
-	r                *rand.Rand
-	nilChance        float64
-	minElements      int
-	maxElements      int
-	maxDepth         int
+	r                    *rand.Rand
+	nilChance            float64
+	minElements          int
+	maxElements          int
+	minStringLen         int
+	maxStringLen         int
+	charset              []charRange
+	hasIntRange          bool
+	minInt, maxInt       int64
+	maxDepth             int
+	makeFuncs            bool
+	interfaceImpls       map[reflect.Type][]reflect.Type
+	onMaxDepth           func(path string)
+	onUnhandled          func(reflect.Value, Continue) bool
+	hasTimeRange         bool
+	minTime, maxTime     time.Time
+	timeLocation         *time.Location
+	allowUnexported      bool
+	skipFields           map[string]bool
+	preserveNonZero      bool
+	edgeCaseChance       float64
+	nilChanceFor         map[reflect.Kind]float64
+	emptyChance          float64
+	kindFuncs            map[reflect.Kind]func(reflect.Value, *rand.Rand)
+	seed                 int64
+	seedMu               *sync.Mutex
+	floatMode            FloatMode
+	hasFloatRange        bool
+	minFloat, maxFloat   float64
+	minDuration          time.Duration
+	maxDuration          time.Duration
+	maxTotalElements     int
+	printableStrings     bool
+	words                []string
+	nonZero              bool
+	numElementsFor       map[reflect.Type][2]int
+	validate             func(obj interface{}) bool
+	anyTypes             []reflect.Type
+	reproOnPanic         bool
+	traceTo              io.Writer
+	elementDistribution  ElementDistribution
+	shuffleFields        bool
+	maxStringBytes       int
+	nonEmpty             map[reflect.Type]bool
+	implWeights          map[reflect.Type][]int
+	leavesOnly           bool
+	skipJSONOmitted      bool
+	enums                map[reflect.Type][]reflect.Value
+	aliasChance          float64
+	asciiKeys            bool
+	maxRecursionFor      map[reflect.Type]int
+	neverNil             map[reflect.Type]bool
+	rawPointers          bool
+	respectValidateTags  bool
+	bytes                *byteSource
+	stopWhenExhausted    bool
+	nilInterfaces        bool
+	nonNilPointersTo     map[reflect.Kind]bool
+	deterministicStreams bool
+	floatDecimalSafe     bool
+	preserveLength       bool
+	assignedRunes        bool
 }
 
 // New returns a new GreenRunner. Customize your GreenRunner further by calling Funcs,
@@ -45,37 +113,207 @@ func New() *GreenRunner {
 
 func NewWithSeed(seed int64) *GreenRunner {
 	f := &GreenRunner{
-		defaultGreenRunFuncs: greenrunFuncMap{
-			reflect.TypeOf(&time.Time{}): reflect.ValueOf(greenrunTime),
-		},
+		defaultGreenRunFuncs: stdlibDefaultFuncs(),
 
-		greenrunFuncs:   greenrunFuncMap{},
-		r:           rand.New(rand.NewSource(seed)),
-		nilChance:   .2,
-		minElements: 1,
-		maxElements: 10,
-		maxDepth:    100,
+		greenrunFuncs:    greenrunFuncMap{},
+		interfaceImpls:   map[reflect.Type][]reflect.Type{},
+		skipFields:       map[string]bool{},
+		nilChanceFor:     map[reflect.Kind]float64{},
+		kindFuncs:        map[reflect.Kind]func(reflect.Value, *rand.Rand){},
+		numElementsFor:   map[reflect.Type][2]int{},
+		nonEmpty:         map[reflect.Type]bool{},
+		implWeights:      map[reflect.Type][]int{},
+		enums:            map[reflect.Type][]reflect.Value{},
+		maxRecursionFor:  map[reflect.Type]int{},
+		neverNil:         map[reflect.Type]bool{},
+		nonNilPointersTo: map[reflect.Kind]bool{},
+		r:                rand.New(rand.NewSource(seed)),
+		seedMu:           &sync.Mutex{},
+		seed:             seed,
+		nilChance:        .2,
+		minElements:      1,
+		maxElements:      10,
+		minStringLen:     0,
+		maxStringLen:     19,
+		charset:          unicodeRanges,
+		maxDepth:         100,
+		maxDuration:      24 * time.Hour,
 	}
 	return f
 }
 
+// Clone returns a new GreenRunner with the same configuration as f, including
+// custom functions, that can be changed without affecting f. Its source of
+// randomness is seeded from f's, so clones of GreenRunners with the same seed
+// generate the same values. Clone may be called from several goroutines at
+// once, as long as f isn't otherwise in use.
+func (f *GreenRunner) Clone() *GreenRunner {
+	c := f.copyConfig()
+	f.seedMu.Lock()
+	c.seed = f.r.Int63()
+	f.seedMu.Unlock()
+	c.r = rand.New(rand.NewSource(c.seed))
+	c.bytes, c.stopWhenExhausted = nil, false
+	return c
+}
+
+// Option changes the configuration of a GreenRunner, as its methods do. Any
+// of them can be made into an Option, e.g.
+//
+//	greenrun.Option(func(f *greenrun.GreenRunner) { f.NilChance(0) })
+//
+// though the most common ones have constructors, such as WithNilChance.
+type Option func(*GreenRunner)
+
+// WithNilChance returns an Option calling NilChance.
+func WithNilChance(p float64) Option {
+	return func(f *GreenRunner) { f.NilChance(p) }
+}
+
+// WithNumElements returns an Option calling NumElements.
+func WithNumElements(atLeast, atMost int) Option {
+	return func(f *GreenRunner) { f.NumElements(atLeast, atMost) }
+}
+
+// WithMaxDepth returns an Option calling MaxDepth.
+func WithMaxDepth(d int) Option {
+	return func(f *GreenRunner) { f.MaxDepth(d) }
+}
+
+// WithStringLen returns an Option calling StringLen.
+func WithStringLen(atLeast, atMost int) Option {
+	return func(f *GreenRunner) { f.StringLen(atLeast, atMost) }
+}
+
+// WithFuncs returns an Option calling Funcs.
+func WithFuncs(greenrunFuncs ...interface{}) Option {
+	return func(f *GreenRunner) { f.Funcs(greenrunFuncs...) }
+}
+
+// With returns a copy of f with opts applied, leaving f untouched, which
+// allows tuning a shared GreenRunner for a single call:
+//
+//	f.With(greenrun.WithNilChance(0)).GreenRun(&obj)
+//
+// The copy is a Clone, with a source of randomness of its own seeded from
+// f's, so a GreenRunner configured once can be shared by goroutines that each
+// call With, and use their copies, concurrently.
+func (f *GreenRunner) With(opts ...Option) *GreenRunner {
+	c := f.Clone()
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// copyConfig returns a copy of f that can be configured without affecting f,
+// sharing its source of randomness.
+func (f *GreenRunner) copyConfig() *GreenRunner {
+	c := *f
+	c.seedMu = &sync.Mutex{}
+	c.greenrunFuncs = greenrunFuncMap{}
+	for t, fn := range f.greenrunFuncs {
+		c.greenrunFuncs[t] = fn
+	}
+	c.defaultGreenRunFuncs = greenrunFuncMap{}
+	for t, fn := range f.defaultGreenRunFuncs {
+		c.defaultGreenRunFuncs[t] = fn
+	}
+	c.interfaceImpls = map[reflect.Type][]reflect.Type{}
+	for t, impls := range f.interfaceImpls {
+		c.interfaceImpls[t] = append([]reflect.Type(nil), impls...)
+	}
+	c.skipFields = map[string]bool{}
+	for name := range f.skipFields {
+		c.skipFields[name] = true
+	}
+	c.nilChanceFor = map[reflect.Kind]float64{}
+	for kind, p := range f.nilChanceFor {
+		c.nilChanceFor[kind] = p
+	}
+	c.kindFuncs = map[reflect.Kind]func(reflect.Value, *rand.Rand){}
+	for kind, fn := range f.kindFuncs {
+		c.kindFuncs[kind] = fn
+	}
+	c.numElementsFor = map[reflect.Type][2]int{}
+	for t, bounds := range f.numElementsFor {
+		c.numElementsFor[t] = bounds
+	}
+	c.nonEmpty = map[reflect.Type]bool{}
+	for t := range f.nonEmpty {
+		c.nonEmpty[t] = true
+	}
+	c.implWeights = map[reflect.Type][]int{}
+	for t, weights := range f.implWeights {
+		c.implWeights[t] = append([]int(nil), weights...)
+	}
+	c.enums = map[reflect.Type][]reflect.Value{}
+	for t, values := range f.enums {
+		c.enums[t] = values
+	}
+	c.maxRecursionFor = map[reflect.Type]int{}
+	for t, n := range f.maxRecursionFor {
+		c.maxRecursionFor[t] = n
+	}
+	c.neverNil = map[reflect.Type]bool{}
+	for t := range f.neverNil {
+		c.neverNil[t] = true
+	}
+	c.nonNilPointersTo = map[reflect.Kind]bool{}
+	for kind := range f.nonNilPointersTo {
+		c.nonNilPointersTo[kind] = true
+	}
+	return &c
+}
+
+// Seed returns the seed f's source of randomness was created with, so that a
+// failure seen with New can be reproduced with NewWithSeed. It is meaningless
+// once RandSource has been called.
+func (f *GreenRunner) Seed() int64 {
+	return f.seed
+}
+
+// Reset replaces f's source of randomness with a new one created from seed,
+// leaving the rest of its configuration alone, so that a GreenRunner reused
+// across tests produces the same values as NewWithSeed(seed) configured the
+// same way. State such as the element count for MaxTotalElements is kept per
+// call to GreenRun, so there is nothing else to reset.
+func (f *GreenRunner) Reset(seed int64) *GreenRunner {
+	f.seed = seed
+	f.r = rand.New(rand.NewSource(seed))
+	f.bytes, f.stopWhenExhausted = nil, false
+	return f
+}
+
 // Funcs adds each entry in greenrunFuncs as a custom greenruning function.
 //
 // Each entry in greenrunFuncs must be a function taking two parameters.
-// The first parameter must be a pointer or map. It is the variable that
+// The first parameter must be a pointer, map or slice. It is the variable that
 // function will fill with random data. The second parameter must be a
 // greenrun.Continue, which will provide a source of randomness and a way
 // to automatically continue greenruning smaller pieces of the first parameter.
+// The function may return an error; a non-nil error stops greenruning, and is
+// returned by GreenRunE (GreenRun panics with it instead).
 //
 // These functions are called sensibly, e.g., if you wanted custom string
 // greenruning, the function `func(s *string, c greenrun.Continue)` would get
 // called and passed the address of strings. Maps and pointers will always
-// be made/new'd for you, ignoring the NilChange option. For slices, it
-// doesn't make much sense to  pre-create them--GreenRunner doesn't know how
-// long you want your slice--so take a pointer to a slice, and make it
+// be made/new'd for you, ignoring the NilChange option. Slices are likewise
+// made for you, with a length picked according to NumElements; if you want
+// to pick the length yourself, take a pointer to a slice, and make it
 // yourself. (If you don't want your map/pointer type pre-made, take a
 // pointer to it, and make it yourself.) See the examples for a range of
 // custom functions.
+//
+// Embedded struct fields are treated like any other field: a custom function
+// for the embedded type fills it as a whole, instead of its fields being
+// filled one by one. Embedded fields of unexported types can't be set, so
+// they are left alone.
+//
+// Each instantiation of a generic type is a type of its own, so a function
+// for *Box[int] isn't used for Box[string], nor for Box[int64]. Functions
+// for a particular instantiation can be used to scope, say, the
+// implementations of a type parameter's interface to that instantiation.
 func (f *GreenRunner) Funcs(greenrunFuncs ...interface{}) *GreenRunner {
 	for i := range greenrunFuncs {
 		v := reflect.ValueOf(greenrunFuncs[i])
@@ -83,14 +321,17 @@ func (f *GreenRunner) Funcs(greenrunFuncs ...interface{}) *GreenRunner {
 			panic("Need only funcs!")
 		}
 		t := v.Type()
-		if t.NumIn() != 2 || t.NumOut() != 0 {
-			panic("Need 2 in and 0 out params!")
+		if t.NumIn() != 2 {
+			panic("Need 2 in params!")
+		}
+		if t.NumOut() > 1 || t.NumOut() == 1 && t.Out(0) != errorType {
+			panic("Need 0 out params, or 1 of type error!")
 		}
 		argT := t.In(0)
 		switch argT.Kind() {
-		case reflect.Ptr, reflect.Map:
+		case reflect.Ptr, reflect.Map, reflect.Slice:
 		default:
-			panic("greenrunFunc must take pointer or map type")
+			panic("greenrunFunc must take pointer, map or slice type")
 		}
 		if t.In(1) != reflect.TypeOf(Continue{}) {
 			panic("greenrunFunc's second parameter must be type greenrun.Continue")
@@ -100,15 +341,149 @@ func (f *GreenRunner) Funcs(greenrunFuncs ...interface{}) *GreenRunner {
 	return f
 }
 
+// DisableDefault stops the package's own greenrun function for t, such as
+// time.Time or net.IP, from being used, so that values of type t are
+// greenruned like any other value of their kind. For time.Time, whose fields
+// are unexported, that means being left zero. Custom functions added with
+// Funcs are unaffected. t must not be a pointer type: pass
+// reflect.TypeOf(time.Time{}), not reflect.TypeOf(&time.Time{}).
+func (f *GreenRunner) DisableDefault(t reflect.Type) *GreenRunner {
+	if t.Kind() == reflect.Ptr {
+		panic(fmt.Sprintf("DisableDefault needs the type itself, not %v", t))
+	}
+	delete(f.defaultGreenRunFuncs, reflect.PtrTo(t))
+	return f
+}
+
+// Enum restricts values of the type of zeroValue to those in allowed, which
+// must all be of that type, picking one at random each time. This suits
+// enumerations of named constants, e.g.
+//
+//	f.Enum(Color(0), Red, Green, Blue)
+//
+// Custom functions for pointers to the type take precedence; Enum takes
+// precedence over custom functions for the type itself.
+func (f *GreenRunner) Enum(zeroValue interface{}, allowed ...interface{}) *GreenRunner {
+	t := reflect.TypeOf(zeroValue)
+	if t == nil {
+		panic("zeroValue must not be nil")
+	}
+	if len(allowed) == 0 {
+		panic("at least one allowed value is required")
+	}
+	values := make([]reflect.Value, len(allowed))
+	for i, a := range allowed {
+		v := reflect.ValueOf(a)
+		if !v.IsValid() || v.Type() != t {
+			panic(fmt.Sprintf("allowed value %#v is not of type %v", a, t))
+		}
+		values[i] = v
+	}
+	f.enums[t] = values
+	return f
+}
+
+// KindFuncs replaces how values of the given primitive kind, such as
+// reflect.Int8 or reflect.String, are filled when no custom function applies
+// to their type. fn is given the value to set and the source of randomness.
+// Kinds that aren't replaced keep their default behavior.
+func (f *GreenRunner) KindFuncs(kind reflect.Kind, fn func(reflect.Value, *rand.Rand)) *GreenRunner {
+	if _, ok := fillFuncMap[kind]; !ok {
+		panic(fmt.Sprintf("%v is not a primitive kind", kind))
+	}
+	f.kindFuncs[kind] = fn
+	return f
+}
+
+// InterfaceImpls registers the types of impls as candidate implementations
+// of the interface type ifaceType. When an interface value of that type is
+// greenruned, one of the registered types is picked at random, greenruned,
+// and stored in it. Implementations that are pointers are always allocated,
+// regardless of NilChance. Calling InterfaceImpls again for the same
+// interface adds to its candidates. Pointers to the interface type, as used
+// for optional fields, are allocated subject to NilChance and then filled in
+// the same way.
+//
+// ifaceType is usually obtained with reflect.TypeOf((*MyIface)(nil)).Elem().
+// Interface values without any registered implementations still cause
+// GreenRun to panic, unless NilInterfaces is used.
+func (f *GreenRunner) InterfaceImpls(ifaceType reflect.Type, impls ...interface{}) *GreenRunner {
+	if ifaceType.Kind() != reflect.Interface {
+		panic("ifaceType must be an interface type")
+	}
+	for _, impl := range impls {
+		t := reflect.TypeOf(impl)
+		if t == nil || !t.Implements(ifaceType) {
+			panic(fmt.Sprintf("%v does not implement %v", t, ifaceType))
+		}
+		f.interfaceImpls[ifaceType] = append(f.interfaceImpls[ifaceType], t)
+		if weights, ok := f.implWeights[ifaceType]; ok {
+			f.implWeights[ifaceType] = append(weights, 1)
+		}
+	}
+	return f
+}
+
+// InterfaceImplsWeighted is like InterfaceImpls, except that each of impls is
+// picked with a probability proportional to the corresponding entry in
+// weights. Implementations registered with InterfaceImpls have a weight of 1.
+// weights must be as long as impls, and no weight may be negative.
+func (f *GreenRunner) InterfaceImplsWeighted(ifaceType reflect.Type, impls []interface{}, weights []int) *GreenRunner {
+	if len(impls) != len(weights) {
+		panic("impls and weights must have the same length")
+	}
+	for _, w := range weights {
+		if w < 0 {
+			panic("weights must be non-negative")
+		}
+	}
+	if _, ok := f.implWeights[ifaceType]; !ok {
+		ones := make([]int, len(f.interfaceImpls[ifaceType]))
+		for i := range ones {
+			ones[i] = 1
+		}
+		f.implWeights[ifaceType] = ones
+	}
+	n := len(f.implWeights[ifaceType])
+	f.InterfaceImpls(ifaceType, impls...)
+	copy(f.implWeights[ifaceType][n:], weights)
+	return f
+}
+
+// AnyTypes sets the candidate types for values of empty interface types,
+// such as interface{} or any, which InterfaceImpls can't cover since every
+// type implements them. When such a value is greenruned, one of types is
+// picked at random, greenruned, and stored in it. This applies wherever the
+// value is, so the values of a map[string]any each get a type of their own.
+// InterfaceImpls registered for a particular empty interface type take
+// precedence. Without any candidates, empty interface values still cause
+// GreenRun to panic, unless NilInterfaces is used.
+func (f *GreenRunner) AnyTypes(types ...reflect.Type) *GreenRunner {
+	f.anyTypes = append([]reflect.Type(nil), types...)
+	return f
+}
+
+// NilInterfaces controls whether interface values without any candidate
+// implementations, from InterfaceImpls or AnyTypes, are left nil rather than
+// making GreenRun panic. It is off by default, so that interfaces that were
+// meant to be filled aren't silently left out.
+func (f *GreenRunner) NilInterfaces(allow bool) *GreenRunner {
+	f.nilInterfaces = allow
+	return f
+}
+
 // RandSource causes f to get values from the given source of randomness.
 // Use if you want deterministic greenruning.
 func (f *GreenRunner) RandSource(s rand.Source) *GreenRunner {
 	f.r = rand.New(s)
+	f.bytes, f.stopWhenExhausted = nil, false
 	return f
 }
 
 // NilChance sets the probability of creating a nil pointer, map, or slice to
-// 'p'. 'p' should be between 0 (no nils) and 1 (all nils), inclusive.
+// 'p'. 'p' should be between 0 (no nils) and 1 (all nils), inclusive. A
+// pointer passed to GreenRun by reference, as in f.GreenRun(&ptr), is never
+// left nil.
 func (f *GreenRunner) NilChance(p float64) *GreenRunner {
 	if p < 0 || p > 1 {
 		panic("p should be between 0 and 1, inclusive.")
@@ -117,8 +492,38 @@ func (f *GreenRunner) NilChance(p float64) *GreenRunner {
 	return f
 }
 
+// NilChanceFor sets the probability of creating a nil value of the given kind,
+// such as reflect.Ptr or reflect.Slice, to 'p', overriding NilChance for that
+// kind. 'p' should be between 0 (no nils) and 1 (all nils), inclusive. Arrays
+// are always filled unless a chance is set for reflect.Array, in which case
+// they are left zero with that probability.
+func (f *GreenRunner) NilChanceFor(kind reflect.Kind, p float64) *GreenRunner {
+	if p < 0 || p > 1 {
+		panic("p should be between 0 and 1, inclusive.")
+	}
+	f.nilChanceFor[kind] = p
+	return f
+}
+
+// EmptyChance sets the probability of creating an empty, but non-nil, map or
+// slice to 'p'. The nil chance is applied first, so overall a collection is
+// nil with probability NilChance, and empty with probability
+// (1-NilChance)*EmptyChance; otherwise it gets a number of elements picked
+// according to NumElements. 'p' should be between 0 (the default) and 1,
+// inclusive.
+func (f *GreenRunner) EmptyChance(p float64) *GreenRunner {
+	if p < 0 || p > 1 {
+		panic("p should be between 0 and 1, inclusive.")
+	}
+	f.emptyChance = p
+	return f
+}
+
 // NumElements sets the minimum and maximum number of elements that will be
-// added to a non-nil map or slice.
+// added to a non-nil map or slice. Maps get exactly the number of elements
+// chosen, as duplicate keys are regenerated, unless the key type can't
+// produce enough distinct values; in that case generation stops after
+// several duplicates in a row, leaving the map smaller.
 func (f *GreenRunner) NumElements(atLeast, atMost int) *GreenRunner {
 	if atLeast > atMost {
 		panic("atLeast must be <= atMost")
@@ -131,165 +536,1600 @@ func (f *GreenRunner) NumElements(atLeast, atMost int) *GreenRunner {
 	return f
 }
 
-func (f *GreenRunner) genElementCount() int {
-	if f.minElements == f.maxElements {
-		return f.minElements
+// An ElementDistribution selects how the number of elements of maps, slices
+// and channels is spread between the bounds set by NumElements.
+type ElementDistribution int
+
+const (
+	// ElementsUniform makes every count equally likely. This is the
+	// default.
+	ElementsUniform ElementDistribution = iota
+	// ElementsGeometric makes each count half as likely as the one below
+	// it, so most collections are near the minimum size.
+	ElementsGeometric
+	// ElementsZipf follows a Zipf distribution, which favors small counts
+	// less strongly than ElementsGeometric, but has a long tail of large
+	// ones.
+	ElementsZipf
+)
+
+// ElementDistribution sets how the number of elements of maps, slices and
+// channels is picked between the bounds set by NumElements.
+func (f *GreenRunner) ElementDistribution(d ElementDistribution) *GreenRunner {
+	if d < ElementsUniform || d > ElementsZipf {
+		panic(fmt.Sprintf("unknown ElementDistribution %d", d))
+	}
+	f.elementDistribution = d
+	return f
+}
+
+// NumElementsFor is like NumElements, but only applies to maps, slices and
+// channels of type t, such as reflect.TypeOf([]Event(nil)), overriding
+// NumElements for them.
+func (f *GreenRunner) NumElementsFor(t reflect.Type, atLeast, atMost int) *GreenRunner {
+	switch t.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Chan:
+	default:
+		panic(fmt.Sprintf("%v is not a map, slice or channel type", t))
+	}
+	if atLeast > atMost {
+		panic("atLeast must be <= atMost")
+	}
+	if atLeast < 0 {
+		panic("atLeast must be >= 0")
+	}
+	f.numElementsFor[t] = [2]int{atLeast, atMost}
+	return f
+}
+
+// MinNonEmpty makes maps and slices of the given types always have at least
+// one element, taking precedence over NilChance, EmptyChance, NumElements and
+// MaxTotalElements. This suits code that assumes, say, s[0] exists.
+func (f *GreenRunner) MinNonEmpty(types ...reflect.Type) *GreenRunner {
+	for _, t := range types {
+		if t.Kind() != reflect.Map && t.Kind() != reflect.Slice {
+			panic(fmt.Sprintf("%v is not a map or slice type", t))
+		}
+		f.nonEmpty[t] = true
+	}
+	return f
+}
+
+// NeverNil makes pointers, maps and slices of the given types always be
+// filled, regardless of NilChance and NilChanceFor. Types implementing
+// NeverNiler are treated this way without being registered.
+func (f *GreenRunner) NeverNil(types ...reflect.Type) *GreenRunner {
+	for _, t := range types {
+		if t.Kind() != reflect.Ptr && t.Kind() != reflect.Map && t.Kind() != reflect.Slice {
+			panic(fmt.Sprintf("%v is not a pointer, map or slice type", t))
+		}
+		f.neverNil[t] = true
+	}
+	return f
+}
+
+// NonNilPointersTo makes pointers to values of the given primitive kinds, such
+// as the *int32 and *string of protobuf's optional scalar fields, always be
+// filled, regardless of NilChance and NilChanceFor. It is narrower than
+// NilChance(0), which affects pointers to structs and collections too.
+func (f *GreenRunner) NonNilPointersTo(kinds ...reflect.Kind) *GreenRunner {
+	for _, kind := range kinds {
+		switch kind {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.String:
+			f.nonNilPointersTo[kind] = true
+		default:
+			panic(fmt.Sprintf("%v is not a primitive kind", kind))
+		}
+	}
+	return f
+}
+
+// StringLen sets the minimum and maximum number of runes in a generated
+// string, inclusive. By default strings have fewer than 20 runes.
+func (f *GreenRunner) StringLen(atLeast, atMost int) *GreenRunner {
+	if atLeast > atMost {
+		panic("atLeast must be <= atMost")
+	}
+	if atLeast < 0 {
+		panic("atLeast must be >= 0")
+	}
+	f.minStringLen = atLeast
+	f.maxStringLen = atMost
+	return f
+}
+
+// Charset sets the ranges of runes that generated strings are made
+// of, replacing the default mix of ASCII, multi-byte and CJK characters. Each
+// range is picked with equal probability, and then a rune within it.
+func (f *GreenRunner) Charset(ranges ...[2]rune) *GreenRunner {
+	if len(ranges) == 0 {
+		panic("at least one range is required")
+	}
+	charset := make([]charRange, len(ranges))
+	for i, r := range ranges {
+		if r[0] > r[1] {
+			panic(fmt.Sprintf("invalid range %q-%q: first must be <= last", r[0], r[1]))
+		}
+		if !hasAssignedRune(r[0], r[1]) {
+			panic(fmt.Sprintf("invalid range %q-%q: no assigned characters", r[0], r[1]))
+		}
+		charset[i] = charRange{r[0], r[1]}
+	}
+	f.charset = charset
+	return f
+}
+
+// MaxStringBytes limits generated strings to n bytes of UTF-8, as database
+// columns often are, dropping runes from the end of longer strings. StringLen
+// still applies, but counts runes, which may take up to 4 bytes each. 0, the
+// default, means no limit.
+func (f *GreenRunner) MaxStringBytes(n int) *GreenRunner {
+	if n < 0 {
+		panic("n should be non-negative.")
+	}
+	f.maxStringBytes = n
+	return f
+}
+
+// AssignedRunes controls whether strings are made only of valid, assigned
+// Unicode characters other than the replacement character, with the last
+// character of each Charset range included. It is off by default, which keeps
+// the values generated for existing seeds; the default Charset holds only
+// assigned characters anyway, but the last character of each range is never
+// chosen.
+func (f *GreenRunner) AssignedRunes(assigned bool) *GreenRunner {
+	f.assignedRunes = assigned
+	return f
+}
+
+// PrintableStrings controls whether generated strings are restricted to
+// printable characters, as defined by unicode.IsPrint, which keeps out
+// control and format characters that trouble strict parsers. Strings can come
+// out shorter than StringLen asks for if the charset holds few printable
+// characters.
+func (f *GreenRunner) PrintableStrings(printable bool) *GreenRunner {
+	f.printableStrings = printable
+	return f
+}
+
+// Wordlist makes generated strings out of words picked from words, separated
+// by spaces, rather than out of random characters. StringLen then bounds the
+// number of words instead of characters. Pass an empty list to go back to
+// random characters.
+func (f *GreenRunner) Wordlist(words []string) *GreenRunner {
+	f.words = append([]string(nil), words...)
+	return f
+}
+
+// ASCIICharset restricts generated strings to printable ASCII characters.
+func (f *GreenRunner) ASCIICharset() *GreenRunner {
+	return f.Charset([2]rune{' ', '~'})
+}
+
+// IntRange restricts generated integers of every kind to [min, max]. For
+// kinds that can't hold the whole range, the range is first clamped to what
+// the kind can represent; unsigned kinds never go below 0.
+func (f *GreenRunner) IntRange(min, max int64) *GreenRunner {
+	if min > max {
+		panic("min must be <= max")
+	}
+	f.hasIntRange = true
+	f.minInt = min
+	f.maxInt = max
+	return f
+}
+
+// A FloatMode selects which values are generated for floating point numbers.
+type FloatMode int
+
+const (
+	// FloatUnit generates values in [0, 1). This is the default.
+	FloatUnit FloatMode = iota
+	// FloatFull generates any bit pattern, including negative, subnormal,
+	// infinite and NaN values.
+	FloatFull
+	// FloatFinite generates any bit pattern that isn't infinite or NaN.
+	FloatFinite
+)
+
+// FloatMode sets which values are generated for float32 and float64 kinds.
+func (f *GreenRunner) FloatMode(mode FloatMode) *GreenRunner {
+	if mode < FloatUnit || mode > FloatFinite {
+		panic(fmt.Sprintf("unknown FloatMode %d", mode))
+	}
+	f.floatMode = mode
+	return f
+}
+
+// FloatDecimalSafe controls whether generated floats are kept to values that
+// encoding/json marshals in plain decimal notation rather than exponent
+// notation: 0, or a magnitude of at least 1e-6 and less than 1e21. Values
+// outside of that are replaced by 0 when too small, NaN included, and by the
+// largest allowed magnitude when too large. With FloatFull and FloatFinite,
+// magnitudes are spread over the decimal orders of magnitude in that range
+// instead. This applies on top of FloatRange and EdgeCaseChance, but not to
+// floats set by custom functions or by range and validate tags.
+func (f *GreenRunner) FloatDecimalSafe(safe bool) *GreenRunner {
+	f.floatDecimalSafe = safe
+	return f
+}
+
+// NonZero controls whether generated integers, floats and strings are kept
+// from being zero, or empty for strings. Zero values are generated again,
+// which slightly skews the distribution away from zero; if a zero value keeps
+// coming up, e.g. because IntRange allows nothing else, it is kept after 100
+// tries.
+func (f *GreenRunner) NonZero(nonZero bool) *GreenRunner {
+	f.nonZero = nonZero
+	return f
+}
+
+// FloatRange restricts generated floats of both kinds to [min, max], taking
+// precedence over FloatMode. Values are spread uniformly over the range.
+func (f *GreenRunner) FloatRange(min, max float64) *GreenRunner {
+	if min > max {
+		panic("min must be <= max")
+	}
+	f.hasFloatRange = true
+	f.minFloat = min
+	f.maxFloat = max
+	return f
+}
+
+// MaxTotalElements caps the number of map, slice, array and channel elements
+// generated in a single call to GreenRun, across all collections, to n. Once
+// it is reached, further maps, slices and channels are empty and further
+// arrays are left zero. This bounds the memory used for deeply nested
+// collections, which MaxDepth alone may not. 0, the default, means no limit.
+func (f *GreenRunner) MaxTotalElements(n int) *GreenRunner {
+	if n < 0 {
+		panic("n should be non-negative.")
+	}
+	f.maxTotalElements = n
+	return f
+}
+
+// genElementCount picks the number of elements of a collection of type t,
+// according to NumElementsFor or NumElements.
+func (f *GreenRunner) genElementCount(t reflect.Type) int {
+	if f.stopWhenExhausted && f.Remaining() == 0 {
+		return 0
+	}
+	min, max := f.minElements, f.maxElements
+	if bounds, ok := f.numElementsFor[t]; ok {
+		min, max = bounds[0], bounds[1]
+	}
+	if min == max {
+		return min
+	}
+	switch f.elementDistribution {
+	case ElementsGeometric:
+		n := min
+		for n < max && f.r.Float64() < .5 {
+			n++
+		}
+		return n
+	case ElementsZipf:
+		return min + int(rand.NewZipf(f.r, 1.5, 1, uint64(max-min)).Uint64())
+	}
+	return min + f.r.Intn(max-min+1)
+}
+
+// genCollectionLen picks the number of elements of a non-nil map or slice of
+// type t.
+func (f *GreenRunner) genCollectionLen(t reflect.Type) int {
+	if f.emptyChance > 0 && f.r.Float64() < f.emptyChance {
+		return 0
 	}
-	return f.minElements + f.r.Intn(f.maxElements-f.minElements+1)
+	return f.genElementCount(t)
 }
 
-func (f *GreenRunner) genShouldFill() bool {
-	return f.r.Float64() > f.nilChance
+func (f *GreenRunner) genShouldFill(kind reflect.Kind) bool {
+	p, ok := f.nilChanceFor[kind]
+	if !ok {
+		p = f.nilChance
+	}
+	return f.r.Float64() > p
 }
 
 // MaxDepth sets the maximum number of recursive greenrun calls that will be made
 // before stopping.  This includes struct members, pointers, and map and slice
-// elements.
+// elements. Depth is measured along the path to each value, not in total, so
+// the fields of a struct all share one level however many there are; only
+// nesting uses up the depth.
 func (f *GreenRunner) MaxDepth(d int) *GreenRunner {
 	f.maxDepth = d
 	return f
 }
 
-// GreenRun recursively fills all of obj's fields with something random.  First
-// this tries to find a custom greenrun function (see Funcs).  If there is no
-// custom function this tests whether the object implements greenrun.Interface and,
-// if so, calls GreenRun on it to greenrun itself.  If that fails, this will see if
-// there is a default greenrun function provided by this package.  If all of that
-// fails, this will generate random values for all primitive fields and then
-// recurse for all non-primitives.
-//
-// This is safe for cyclic or tree-like structs, up to a limit.  Use the
-// MaxDepth method to adjust how deep you need it to recurse.
-//
-// obj must be a pointer. Only exported (public) fields can be set (thanks,
-// golang :/ ) Intended for tests, so will panic on bad input or unimplemented
-// fields.
-func (f *GreenRunner) GreenRun(obj interface{}) {
-	v := reflect.ValueOf(obj)
-	if v.Kind() != reflect.Ptr {
-		panic("needed ptr!")
+// MaxRecursionPerType limits how many values of type t may be nested inside
+// one another along any path to n, which should be at least 1. Once the limit
+// is reached, pointers to t are left nil, and slices and maps of t are made
+// empty, unless a nil chance makes them nil. This bounds recursive types such
+// as trees, whose size can grow exponentially long before MaxDepth is hit.
+func (f *GreenRunner) MaxRecursionPerType(t reflect.Type, n int) *GreenRunner {
+	if n < 1 {
+		panic("n must be at least 1.")
 	}
-	v = v.Elem()
-	f.greenrunWithContext(v, 0)
+	f.maxRecursionFor[t] = n
+	return f
 }
 
-// GreenRunNoCustom is just like GreenRun, except that any custom greenrun function for
-// obj's type will not be called and obj will not be tested for greenrun.Interface
-// conformance.  This applies only to obj and not other instances of obj's
-// type.
-// Not safe for cyclic or tree-like structs!
-// obj must be a pointer. Only exported (public) fields can be set (thanks, golang :/ )
-// Intended for tests, so will panic on bad input or unimplemented fields.
-func (f *GreenRunner) GreenRunNoCustom(obj interface{}) {
-	v := reflect.ValueOf(obj)
-	if v.Kind() != reflect.Ptr {
-		panic("needed ptr!")
+// TimeRange makes the default time.Time greenrun function pick instants
+// between min and max, inclusive. By default times fall within about 1000
+// years after the Unix epoch. The times picked are in the location set by
+// TimeLocation, whatever those of min and max.
+func (f *GreenRunner) TimeRange(min, max time.Time) *GreenRunner {
+	if max.Before(min) {
+		panic("min must not be after max")
+	}
+	f.hasTimeRange = true
+	f.minTime = min
+	f.maxTime = max
+	return f
+}
+
+// TimeLocation sets the location of the times picked by the default time.Time
+// greenrun function, which is UTC by default. Either way, they carry no
+// monotonic clock reading, so they survive round trips through formats such
+// as time.RFC3339Nano unchanged.
+func (f *GreenRunner) TimeLocation(loc *time.Location) *GreenRunner {
+	if loc == nil {
+		panic("loc must not be nil")
+	}
+	f.timeLocation = loc
+	return f
+}
+
+// DurationRange makes the default time.Duration greenrun function pick
+// durations between min and max, inclusive. By default durations fall
+// between 0 and 24 hours.
+func (f *GreenRunner) DurationRange(min, max time.Duration) *GreenRunner {
+	if min > max {
+		panic("min must be <= max")
+	}
+	f.minDuration = min
+	f.maxDuration = max
+	return f
+}
+
+// LeavesOnly controls whether only primitive values, such as numbers and
+// strings, are filled in, along with the fields of structs held by value.
+// Maps, slices, channels and pointers are then left nil, and arrays zero,
+// which keeps objects small and greenruning fast when only scalar fields
+// matter. A pointer passed to GreenRun by reference is still filled.
+func (f *GreenRunner) LeavesOnly(leavesOnly bool) *GreenRunner {
+	f.leavesOnly = leavesOnly
+	return f
+}
+
+// ShuffleFields controls whether struct fields are greenruned in a random
+// order, rather than in the order they are declared. This matters to custom
+// functions with state shared between fields. Since it changes the order in
+// which random values are drawn, it changes the values generated for a seed.
+func (f *GreenRunner) ShuffleFields(shuffle bool) *GreenRunner {
+	f.shuffleFields = shuffle
+	return f
+}
+
+// SkipFields causes struct fields with any of the given names, in any struct,
+// to be left untouched, as if they were tagged `greenrun:"-"`. Names are
+// matched case-sensitively.
+func (f *GreenRunner) SkipFields(names ...string) *GreenRunner {
+	for _, name := range names {
+		f.skipFields[name] = true
+	}
+	return f
+}
+
+// SkipJSONOmitted controls whether struct fields tagged `json:"-"`, which
+// encoding/json ignores, are left untouched, as if they were tagged
+// `greenrun:"-"`. Such fields are skipped even if they also have a greenrun
+// tag. Fields tagged `json:"-,"`, which encoding/json names "-", are still
+// filled.
+func (f *GreenRunner) SkipJSONOmitted(skip bool) *GreenRunner {
+	f.skipJSONOmitted = skip
+	return f
+}
+
+// PreserveNonZero controls whether values that are already set are kept. When
+// enabled, only values that are zero are greenruned; non-nil pointers, maps,
+// slices and so on are left alone entirely. Structs that aren't zero are still
+// descended into, without calling any custom greenrun function for them, so
+// that their zero fields get filled in.
+func (f *GreenRunner) PreserveNonZero(preserve bool) *GreenRunner {
+	f.preserveNonZero = preserve
+	return f
+}
+
+// PreserveLength controls whether slices that aren't nil keep their length,
+// with only their elements being greenruned, rather than being replaced by
+// new slices. This allows filling a make([]T, 50) in place, including when it
+// is passed to GreenRun directly, as in f.GreenRun(&s). Nil slices are made
+// as usual.
+func (f *GreenRunner) PreserveLength(preserve bool) *GreenRunner {
+	f.preserveLength = preserve
+	return f
+}
+
+// AliasChance sets the probability of pointing a pointer at a value of its
+// type that was already allocated and filled while greenruning the same
+// object, rather than at a new one, to 'p'. This helps find bugs in code that
+// mutates through pointers it assumes are distinct. A pointer is never aimed
+// at a value enclosing it, so no cycles are made. 'p' should be between 0 (the
+// default) and 1, inclusive.
+func (f *GreenRunner) AliasChance(p float64) *GreenRunner {
+	if p < 0 || p > 1 {
+		panic("p should be between 0 and 1, inclusive.")
+	}
+	f.aliasChance = p
+	return f
+}
+
+// ASCIIKeys controls whether the keys of maps with string-kind keys are made
+// of printable ASCII characters only, regardless of Charset and Wordlist.
+// Map values, and keys of any other kind, are not affected. Custom greenrun
+// functions and enums registered for the key type still take precedence.
+func (f *GreenRunner) ASCIIKeys(ascii bool) *GreenRunner {
+	f.asciiKeys = ascii
+	return f
+}
+
+// EdgeCaseChance sets the probability of filling a number with a boundary
+// value instead of a uniformly random one to 'p'. For integers these are the
+// smallest and largest values the type holds, as well as -1, 0 and 1; an
+// IntRange narrows them to its own bounds. For floats they are zero, negative
+// zero, the infinities, NaN, and the smallest and largest magnitudes the type
+// holds. 'p' should be between 0 (the default) and 1, inclusive.
+func (f *GreenRunner) EdgeCaseChance(p float64) *GreenRunner {
+	if p < 0 || p > 1 {
+		panic("p should be between 0 and 1, inclusive.")
+	}
+	f.edgeCaseChance = p
+	return f
+}
+
+// AllowUnexported controls whether unexported struct fields are filled in
+// too. Since reflection won't set them, this reaches them through package
+// unsafe, bypassing whatever invariants their package maintains. It is meant
+// for testing your own types only, and is off by default.
+func (f *GreenRunner) AllowUnexported(allow bool) *GreenRunner {
+	f.allowUnexported = allow
+	return f
+}
+
+// RespectValidateTags controls whether struct fields are filled so as to
+// satisfy the constraints in their `validate` tags, as used by
+// github.com/go-playground/validator. Only these constraints are supported,
+// on fields of numeric, string, slice and map kinds:
+//
+//   - min=n and max=n bound numbers, and the number of runes in strings or
+//     of elements in slices and maps, inclusively; such strings are made of
+//     runes from the Charset even if a Wordlist is set;
+//   - len=n requires exactly that number, or length;
+//   - oneof=a b c picks one of the space-separated strings or integers.
+//
+// Other constraints, including those following dive, are ignored, as are
+// validate tags on fields of other kinds, such as pointers. A `greenrun` tag
+// on the same field takes precedence.
+func (f *GreenRunner) RespectValidateTags(respect bool) *GreenRunner {
+	f.respectValidateTags = respect
+	return f
+}
+
+// DeterministicStreams controls whether each struct field is greenruned with
+// a source of randomness of its own, derived from its path within the object
+// and from f's source. This keeps the values of a field the same when the
+// randomness consumed elsewhere changes, e.g. because a custom function was
+// added for another field, or another field was added or removed, which makes
+// golden tests much less fragile. Values still vary from one call to
+// GreenRun to the next. Enabling it changes the values generated for a seed.
+func (f *GreenRunner) DeterministicStreams(enable bool) *GreenRunner {
+	f.deterministicStreams = enable
+	return f
+}
+
+// GreenRunRawPointers controls whether uintptr values are greenruned like
+// other unsigned integers, and unsafe.Pointer values pointed at freshly
+// allocated random memory. By default both are left zero, since code that
+// turns a random uintptr back into a pointer can crash. Even when enabled,
+// unsafe.Pointer values never point anywhere meaningful.
+func (f *GreenRunner) GreenRunRawPointers(raw bool) *GreenRunner {
+	f.rawPointers = raw
+	return f
+}
+
+// OnMaxDepth sets a function to be called with the path of each value that is
+// left untouched because MaxDepth was reached, e.g. "MyType.Next.Next". This
+// helps tell whether MaxDepth is too low for a type. Pass nil to remove it.
+func (f *GreenRunner) OnMaxDepth(fn func(path string)) *GreenRunner {
+	f.onMaxDepth = fn
+	return f
+}
+
+// OnUnhandled sets a function to be consulted for values GreenRun doesn't
+// know how to fill, such as complex numbers, or channels, funcs and
+// interfaces without a way to fill them. If fn returns true the value is
+// considered handled; otherwise GreenRun panics as usual. Pass nil to remove
+// it.
+func (f *GreenRunner) OnUnhandled(fn func(v reflect.Value, c Continue) bool) *GreenRunner {
+	f.onUnhandled = fn
+	return f
+}
+
+// Validate sets a function to check objects once GreenRun has filled them,
+// which is useful for invariants spanning several fields, such as a start
+// time preceding an end time. It is passed the pointer given to GreenRun; if
+// it returns false, the object is restored to its state before GreenRun and
+// greenruned again. After 100 rejected attempts GreenRunE gives up with an
+// error, and GreenRun panics, as they do for a nil pointer, which has no
+// object to validate. Pass nil to remove it.
+func (f *GreenRunner) Validate(fn func(obj interface{}) bool) *GreenRunner {
+	f.validate = fn
+	return f
+}
+
+// ReproOnPanic controls whether each call to GreenRun, and its variants, logs
+// how to reproduce it if it panics, e.g. in a custom greenrun function, before
+// letting the panic continue. To make that possible, each call then draws a
+// new seed from f's source of randomness and greenruns with a generator made
+// from it; the log, written with package log, names that seed. The values
+// generated therefore differ from those generated without ReproOnPanic.
+func (f *GreenRunner) ReproOnPanic(enabled bool) *GreenRunner {
+	f.reproOnPanic = enabled
+	return f
+}
+
+// TraceTo sets a writer to log the decisions made while greenruning to, one
+// line per decision, such as making a pointer nil or picking the number of
+// elements of a slice, along with the path and kind of the value concerned.
+// This helps explain why an object came out the way it did. Pass nil, the
+// default, to turn it off.
+func (f *GreenRunner) TraceTo(w io.Writer) *GreenRunner {
+	f.traceTo = w
+	return f
+}
+
+// GreenRunFuncs controls whether func-typed values are filled in. When
+// enabled, each func value is replaced (subject to NilChance) with a
+// function that ignores its arguments and returns freshly greenruned values
+// for each of its results. When disabled, the default, func values cause
+// GreenRun to panic. Functions generated by GreenRunContext return zero
+// values once its context is done.
+//
+// The generated functions hold on to the GreenRunner's source of randomness,
+// so they must not be called concurrently with each other or with other uses
+// of the GreenRunner unless that source is safe for concurrent use.
+func (f *GreenRunner) GreenRunFuncs(enabled bool) *GreenRunner {
+	f.makeFuncs = enabled
+	return f
+}
+
+// GreenRun recursively fills all of obj's fields with something random.  First
+// this tries to find a custom greenrun function (see Funcs).  If there is no
+// custom function this tests whether the object implements greenrun.Interface and,
+// if so, calls GreenRun on it to greenrun itself.  If that fails, this will see if
+// there is a default greenrun function provided by this package.  If all of that
+// fails, this will generate random values for all primitive fields and then
+// recurse for all non-primitives.
+//
+// This is safe for cyclic or tree-like structs, up to a limit.  An object
+// that is being filled, such as obj itself or the one a custom function was
+// given, is left alone if Continue.GreenRun is asked to fill it again, so a
+// custom function handing its own object back doesn't recurse.  As freshly
+// allocated objects are never revisited, recursion is otherwise bounded by
+// MaxDepth; use it to adjust how deep you need it to recurse.
+//
+// Struct fields tagged with `greenrun:"-"` are left untouched, and are not
+// descended into. Numeric fields tagged with `greenrun:"range=min:max"` are
+// filled with a value between min and max, inclusive.
+//
+// obj must be a pointer. Only exported (public) fields can be set (thanks,
+// golang :/ ) unless AllowUnexported is used. Intended for tests, so will
+// panic on bad input or unimplemented fields. Use GreenRunE to get an error
+// instead.
+func (f *GreenRunner) GreenRun(obj interface{}) {
+	if err := f.GreenRunE(obj); err != nil {
+		panic(err)
+	}
+}
+
+// Fuzz is equivalent to GreenRun. It eases migrating code written for
+// gofuzz.
+func (f *GreenRunner) Fuzz(obj interface{}) {
+	f.GreenRun(obj)
+}
+
+// GreenRunE is like GreenRun, except that bad input and values that can't be
+// greenruned are reported as an error, naming the offending type and its path
+// within obj, rather than by panicking. obj may be partially filled when an
+// error is returned. Panics raised by custom greenrun functions are not
+// recovered.
+func (f *GreenRunner) GreenRunE(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr {
+		return fmt.Errorf("greenrun: needed ptr, got %T", obj)
+	}
+	return f.greenrunWithContext(context.Background(), v, 0, nil)
+}
+
+// Stats describes what a single call to GreenRunStats generated, to help tune
+// options such as NilChance and NumElements.
+type Stats struct {
+	// NilsGenerated counts the pointers, maps, slices, channels, funcs and
+	// interfaces left nil, other than by functions passed to Funcs.
+	NilsGenerated int
+	// ElementsGenerated counts the map, slice, array and channel elements
+	// generated, as limited by MaxTotalElements.
+	ElementsGenerated int
+	// MaxDepthReached is the deepest level reached, as counted against
+	// MaxDepth.
+	MaxDepthReached int
+	// CustomFuncCalls counts the calls made to custom greenrun functions,
+	// default ones and greenrun.Interface implementations.
+	CustomFuncCalls int
+}
+
+// GreenRunStats is like GreenRun, except that it also returns statistics
+// about the values generated. When Validate is used, they describe the
+// attempt that was accepted.
+func (f *GreenRunner) GreenRunStats(obj interface{}) Stats {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr {
+		panic("needed ptr!")
+	}
+	var stats Stats
+	if err := f.greenrunWithContext(context.Background(), v, 0, &stats); err != nil {
+		panic(err)
+	}
+	return stats
+}
+
+// GreenRunContext is like GreenRunE, except that it gives up and returns
+// ctx's error once ctx is done. This allows bounding the time spent
+// greenruning very large objects. obj may be partially filled when an error
+// is returned.
+func (f *GreenRunner) GreenRunContext(ctx context.Context, obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr {
+		return fmt.Errorf("greenrun: needed ptr, got %T", obj)
+	}
+	return f.greenrunWithContext(ctx, v, 0, nil)
+}
+
+// GreenRunValue is like GreenRun, except that it fills in v itself, which is
+// useful when a reflect.Value is all that is at hand. It panics if v can't be
+// set, e.g. because it wasn't obtained through a pointer.
+func (f *GreenRunner) GreenRunValue(v reflect.Value) {
+	if !v.CanSet() {
+		panic("needed settable value!")
+	}
+	if err := f.greenrunWithContext(context.Background(), v.Addr(), 0, nil); err != nil {
+		panic(err)
+	}
+}
+
+// GreenRunParallel greenruns each of objs, which must all be pointers, using
+// the given number of goroutines. Each goroutine works on its own Clone of
+// f, so that no source of randomness is shared, and objs are dealt out to
+// them in turn. The values generated therefore depend on workers as well as
+// on f's seed: the same seed and worker count give the same values. Custom
+// greenrun functions are shared between goroutines, so they must be safe for
+// concurrent use. Like GreenRun, it panics on bad input. Panics in the
+// goroutines, such as from custom functions, are recovered, and GreenRunParallel
+// panics with the first of them, by goroutine, once all are done.
+func (f *GreenRunner) GreenRunParallel(objs []interface{}, workers int) {
+	if workers < 1 {
+		panic("workers should be at least 1.")
+	}
+	errs := make([]error, len(objs))
+	panics := make([]interface{}, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		c := f.Clone()
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			defer func() {
+				panics[w] = recover()
+			}()
+			for i := w; i < len(objs); i += workers {
+				errs[i] = c.GreenRunE(objs[i])
+			}
+		}(w)
+	}
+	wg.Wait()
+	for _, r := range panics {
+		if r != nil {
+			panic(r)
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// GreenRunUnique sets the slice pointed to by objs to n greenruned elements,
+// none of which is deeply equal to another. An element that comes out equal
+// to an earlier one is greenruned again, up to uniqueAttempts times; if the
+// element type has too few possible values for that to succeed, the last
+// duplicate is kept, so the result may then contain repeats. Like GreenRun,
+// it panics on bad input.
+func (f *GreenRunner) GreenRunUnique(objs interface{}, n int) {
+	v := reflect.ValueOf(objs)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		panic("needed ptr to slice!")
+	}
+	if n < 0 {
+		panic("n should be non-negative.")
+	}
+	s := reflect.MakeSlice(v.Elem().Type(), n, n)
+	for i := 0; i < n; i++ {
+		elem := s.Index(i)
+		for tries := 0; tries < uniqueAttempts; tries++ {
+			elem.Set(reflect.Zero(elem.Type()))
+			if err := f.greenrunWithContext(context.Background(), elem.Addr(), 0, nil); err != nil {
+				panic(err)
+			}
+			if !containsEqual(s.Slice(0, i), elem) {
+				break
+			}
+		}
+	}
+	v.Elem().Set(s)
+}
+
+// uniqueAttempts is how many times GreenRunUnique greenruns an element that
+// duplicates an earlier one before keeping it anyway.
+const uniqueAttempts = 10
+
+// containsEqual reports whether the slice s holds an element deeply equal to v.
+func containsEqual(s, v reflect.Value) bool {
+	for i := 0; i < s.Len(); i++ {
+		if reflect.DeepEqual(s.Index(i).Interface(), v.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// GreenRunNoCustom is just like GreenRun, except that any custom greenrun function for
+// obj's type will not be called and obj will not be tested for greenrun.Interface
+// conformance.  This applies only to obj and not other instances of obj's
+// type.
+// Not safe for cyclic or tree-like structs!
+// obj must be a pointer. Only exported (public) fields can be set (thanks, golang :/ )
+// Intended for tests, so will panic on bad input or unimplemented fields.
+func (f *GreenRunner) GreenRunNoCustom(obj interface{}) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr {
+		panic("needed ptr!")
+	}
+	if err := f.greenrunWithContext(context.Background(), v, flagNoCustomGreenRun, nil); err != nil {
+		panic(err)
+	}
+}
+
+// mapKeyAttempts is how many duplicate keys in a row are generated for a map
+// before giving up on reaching the requested number of elements.
+const mapKeyAttempts = 10
+
+const (
+	// Do not try to find a custom greenrun function.  Does not apply recursively.
+	flagNoCustomGreenRun uint64 = 1 << iota
+	// Do not leave a pointer nil.  Does not apply recursively.
+	flagNoNil
+)
+
+// validateAttempts is how many times an object is greenruned in search of one
+// accepted by the Validate function.
+const validateAttempts = 100
+
+// greenrunWithContext greenruns the value pointed to by p in a new run, which
+// stops early once ctx is done, and repeats it until the Validate function,
+// if any, accepts the result.
+func (f *GreenRunner) greenrunWithContext(ctx context.Context, p reflect.Value, flags uint64, stats *Stats) error {
+	if f.reproOnPanic {
+		return f.greenrunRepro(ctx, p, flags, stats)
+	}
+	if f.validate == nil {
+		return f.greenrunOnce(ctx, p, flags, stats)
+	}
+	if p.IsNil() {
+		return fmt.Errorf("greenrun: can't validate a nil %v", p.Type())
+	}
+	orig := reflect.New(p.Elem().Type()).Elem()
+	orig.Set(p.Elem())
+	for i := 0; i < validateAttempts; i++ {
+		if i > 0 {
+			p.Elem().Set(orig)
+		}
+		if err := f.greenrunOnce(ctx, p, flags, stats); err != nil {
+			return err
+		}
+		if f.validate(p.Interface()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("greenrun: no valid %v found in %d attempts", p.Elem().Type(), validateAttempts)
+}
+
+// greenrunRepro is like greenrunWithContext, except that it uses a fresh
+// source of randomness, seeded from f's, and logs how to reproduce the run if
+// it panics.
+func (f *GreenRunner) greenrunRepro(ctx context.Context, p reflect.Value, flags uint64, stats *Stats) error {
+	seed := f.r.Int63()
+	saved := f.r
+	f.r = rand.New(rand.NewSource(seed))
+	f.reproOnPanic = false
+	defer func() {
+		f.r = saved
+		f.reproOnPanic = true
+		if r := recover(); r != nil {
+			log.Printf("greenrun: panic while greenruning %v; to reproduce, greenrun it with greenrun.NewWithSeed(%d), configured as before but without ReproOnPanic", p.Type(), seed)
+			panic(r)
+		}
+	}()
+	return f.greenrunWithContext(ctx, p, flags, stats)
+}
+
+// greenrunOnce greenruns the value pointed to by p in a new run, which stops
+// early once ctx is done.
+func (f *GreenRunner) greenrunOnce(ctx context.Context, p reflect.Value, flags uint64, stats *Stats) (err error) {
+	fc := &greenrunerContext{greenruner: f, ctx: ctx, root: p.Type().Elem().Name()}
+	defer func() {
+		if r := recover(); r != nil {
+			gp, ok := r.(greenrunPanic)
+			if !ok {
+				panic(r)
+			}
+			err = gp.err
+		}
+	}()
+	if f.deterministicStreams {
+		fc.streamSeed = f.r.Uint64()
+	}
+	fc.visit(p)
+	// The value passed to GreenRun should always be filled, even if it's a
+	// pointer.
+	fc.doGreenRun(p.Elem(), flags|flagNoNil)
+	if stats != nil {
+		fc.stats.ElementsGenerated = fc.elements
+		*stats = fc.stats
+	}
+	return nil
+}
+
+// greenrunPanic is used to unwind a greenruning run once an error is found.
+// It is recovered, and its error returned, by greenrunWithContext.
+type greenrunPanic struct {
+	err error
+}
+
+// greenrunerContext carries context about a single greenruning run, which lets GreenRunner
+// be thread-safe.
+type greenrunerContext struct {
+	greenruner *GreenRunner
+	ctx        context.Context
+	curDepth   int
+
+	// root and path describe where in the object being greenruned we are.
+	root string
+	path []pathSegment
+
+	// visited holds the pointers to the objects being filled: the one passed
+	// to GreenRun and those passed to the custom functions currently running.
+	visited map[visitKey]bool
+
+	// elements counts the map, slice, array and channel elements generated
+	// so far in this run, for MaxTotalElements.
+	elements int
+
+	// allocated holds the pointers allocated so far in this run, by type,
+	// for AliasChance.
+	allocated map[reflect.Type][]reflect.Value
+
+	// asciiKey is set while greenruning a string map key, for ASCIIKeys.
+	asciiKey bool
+
+	// stats describes what this run has generated so far.
+	stats Stats
+
+	// streamSeed is mixed with the path of each struct field to seed its
+	// source of randomness, for DeterministicStreams.
+	streamSeed uint64
+
+	// recursion counts, for the types limited by MaxRecursionPerType, how
+	// many values of each type enclose the current one, itself included.
+	recursion map[reflect.Type]int
+
+	// split is set for the runs of Continues from Split.
+	split bool
+}
+
+// enterType records that a value of type t is being greenruned, for
+// MaxRecursionPerType, and returns a function undoing that.
+func (fc *greenrunerContext) enterType(t reflect.Type) func() {
+	if _, ok := fc.greenruner.maxRecursionFor[t]; !ok {
+		return func() {}
+	}
+	if fc.recursion == nil {
+		fc.recursion = map[reflect.Type]int{}
+	}
+	fc.recursion[t]++
+	return func() { fc.recursion[t]-- }
+}
+
+// neverNil reports whether values of the pointer, map or slice type t must be
+// filled regardless of NilChance.
+func (fc *greenrunerContext) neverNil(t reflect.Type) bool {
+	return fc.greenruner.neverNil[t] || t.Implements(neverNilerType)
+}
+
+// recursionExhausted reports whether no more values of type t may be nested
+// within the current one, as set by MaxRecursionPerType.
+func (fc *greenrunerContext) recursionExhausted(t reflect.Type) bool {
+	n, ok := fc.greenruner.maxRecursionFor[t]
+	return ok && fc.recursion[t] >= n
+}
+
+// tryAlias points the pointer v, with the probability set by AliasChance, at
+// a value allocated and filled earlier in this run, and reports whether it
+// did.
+func (fc *greenrunerContext) tryAlias(v reflect.Value) bool {
+	f := fc.greenruner
+	if f.aliasChance == 0 {
+		return false
+	}
+	prev := fc.allocated[v.Type()]
+	if len(prev) == 0 || f.r.Float64() >= f.aliasChance {
+		return false
+	}
+	fc.trace(v, "aliased pointer")
+	v.Set(prev[f.r.Intn(len(prev))])
+	return true
+}
+
+// takeNonEmpty is like takeElements, except that it grants at least one
+// element if nonEmpty is set, regardless of the budget.
+func (fc *greenrunerContext) takeNonEmpty(n int, nonEmpty bool) int {
+	n = fc.takeElements(n)
+	if nonEmpty && n == 0 {
+		fc.elements++
+		return 1
+	}
+	return n
+}
+
+// takeElements reserves up to n elements of the MaxTotalElements budget and
+// returns how many were granted.
+func (fc *greenrunerContext) takeElements(n int) int {
+	if max := fc.greenruner.maxTotalElements; max > 0 && fc.elements+n > max {
+		n = max - fc.elements
+	}
+	fc.elements += n
+	return n
+}
+
+// visitKey identifies a pointer by its address and type, since e.g. a struct
+// and its first field share an address. Holding the address as an
+// unsafe.Pointer keeps the object alive, so that its address can't be reused
+// by another one while the key is held.
+type visitKey struct {
+	addr unsafe.Pointer
+	typ  reflect.Type
+}
+
+// visit records that the object the non-nil pointer p points to is being
+// filled, and reports whether it wasn't already. If it returns true, the
+// caller must call leave once the object is filled.
+func (fc *greenrunerContext) visit(p reflect.Value) bool {
+	k := visitKey{p.UnsafePointer(), p.Type()}
+	if fc.visited[k] {
+		return false
+	}
+	if fc.visited == nil {
+		fc.visited = map[visitKey]bool{}
+	}
+	fc.visited[k] = true
+	return true
+}
+
+// leave records that the object p points to, which visit was called for, is
+// filled.
+func (fc *greenrunerContext) leave(p reflect.Value) {
+	delete(fc.visited, visitKey{p.UnsafePointer(), p.Type()})
+}
+
+// pathSegment is one step from a value to a value within it.
+type pathSegment struct {
+	field string        // set for struct fields
+	index int           // set for slice, array and channel elements
+	key   reflect.Value // set for map values
+	isKey bool          // set for map keys
+
+	// For map keys, index counts the keys made so far for the map, and
+	// attempt the duplicates made since the last new one. They tell apart
+	// the streams of keys, which share a path, for DeterministicStreams.
+	attempt int
+}
+
+func (s pathSegment) String() string {
+	switch {
+	case s.field != "":
+		return "." + s.field
+	case s.key.IsValid():
+		return fmt.Sprintf("[%#v]", s.key.Interface())
+	case s.isKey:
+		return "[key]"
+	default:
+		return fmt.Sprintf("[%d]", s.index)
+	}
+}
+
+// trace writes a line about the value v at the current path to the TraceTo
+// writer, if any.
+func (fc *greenrunerContext) trace(v reflect.Value, format string, args ...interface{}) {
+	w := fc.greenruner.traceTo
+	if w == nil {
+		return
+	}
+	fmt.Fprintf(w, "%v (%v): %v\n", fc.pathString(), v.Kind(), fmt.Sprintf(format, args...))
+}
+
+// stream returns the source of randomness for the struct field reached from
+// the current value by seg, for DeterministicStreams.
+func (fc *greenrunerContext) stream(seg pathSegment) *rand.Rand {
+	h := fnv.New64a()
+	io.WriteString(h, fc.root)
+	for _, s := range append(fc.path[:len(fc.path):len(fc.path)], seg) {
+		io.WriteString(h, s.String())
+		if s.isKey {
+			fmt.Fprintf(h, "#%d.%d", s.index, s.attempt)
+		}
+	}
+	return rand.New(&splitMixSource{state: fc.streamSeed ^ h.Sum64()})
+}
+
+// doGreenRunAt greenruns v, which is reached from the current value by seg.
+func (fc *greenrunerContext) doGreenRunAt(seg pathSegment, v reflect.Value, flags uint64) {
+	fc.path = append(fc.path, seg)
+	defer func() { fc.path = fc.path[:len(fc.path)-1] }()
+	fc.doGreenRun(v, flags)
+}
+
+// pathString renders the path to the current value, e.g. "MyType.Items[2].Name".
+func (fc *greenrunerContext) pathString() string {
+	var b strings.Builder
+	b.WriteString(fc.root)
+	for _, seg := range fc.path {
+		b.WriteString(seg.String())
+	}
+	return b.String()
+}
+
+// setNil leaves the pointer, map, slice, channel, func or interface v nil,
+// tracing why and counting it in the Stats.
+func (fc *greenrunerContext) setNil(v reflect.Value, why string) {
+	fc.trace(v, "%s", why)
+	fc.stats.NilsGenerated++
+	v.Set(reflect.Zero(v.Type()))
+}
+
+// fail stops the current run, which will report an error made from format
+// and args, along with the path to the current value.
+// format may use %w to wrap an error.
+func (fc *greenrunerContext) fail(format string, args ...interface{}) {
+	err := fmt.Errorf(format, args...)
+	if path := fc.pathString(); path != "" {
+		err = fmt.Errorf("greenrun: %w at %v", err, path)
+	} else {
+		err = fmt.Errorf("greenrun: %w", err)
+	}
+	panic(greenrunPanic{err})
+}
+
+func (fc *greenrunerContext) doGreenRun(v reflect.Value, flags uint64) {
+	if fc.ctx != nil {
+		if err := fc.ctx.Err(); err != nil {
+			panic(greenrunPanic{err})
+		}
+	}
+	if fc.curDepth >= fc.greenruner.maxDepth {
+		fc.trace(v, "left alone, max depth reached")
+		if fc.greenruner.onMaxDepth != nil {
+			fc.greenruner.onMaxDepth(fc.pathString())
+		}
+		return
+	}
+	fc.curDepth++
+	defer func() { fc.curDepth-- }()
+	if fc.curDepth > fc.stats.MaxDepthReached {
+		fc.stats.MaxDepthReached = fc.curDepth
+	}
+
+	if !v.CanSet() {
+		return
+	}
+
+	// A custom function handing its own value back through
+	// GreenRunNoCustom doesn't nest a new value.
+	if flags&flagNoCustomGreenRun == 0 || fc.curDepth == 1 {
+		defer fc.enterType(v.Type())()
+	}
+
+	if fc.greenruner.preserveNonZero && !v.IsZero() {
+		if v.Kind() != reflect.Struct {
+			fc.trace(v, "preserved")
+			return
+		}
+		flags |= flagNoCustomGreenRun
+	}
+
+	if flags&flagNoCustomGreenRun == 0 {
+		// Check for both pointer and non-pointer custom functions.
+		if v.CanAddr() && fc.tryCustom(v.Addr()) {
+			fc.trace(v, "filled by custom function")
+			return
+		}
+		if fc.tryCustom(v) {
+			fc.trace(v, "filled by custom function")
+			return
+		}
+	}
+
+	if fn, ok := fillFuncMap[v.Kind()]; ok {
+		for tries := 1; ; tries++ {
+			fc.fillPrimitive(v, fn)
+			if fc.greenruner.floatDecimalSafe {
+				decimalSafeFloat(v)
+			}
+			if !fc.greenruner.nonZero || !v.IsZero() || tries == nonZeroAttempts {
+				return
+			}
+			switch v.Kind() {
+			case reflect.Bool, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+				return
+			case reflect.Uintptr:
+				if !fc.greenruner.rawPointers {
+					return
+				}
+			}
+		}
+	}
+	if fc.greenruner.leavesOnly {
+		switch v.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Chan:
+			fc.setNil(v, "left nil, leaves only")
+			return
+		case reflect.Array:
+			fc.trace(v, "left zero, leaves only")
+			v.Set(reflect.Zero(v.Type()))
+			return
+		case reflect.Ptr:
+			if flags&flagNoNil == 0 {
+				fc.setNil(v, "left nil, leaves only")
+				return
+			}
+		}
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		nonEmpty := fc.greenruner.nonEmpty[v.Type()]
+		if fc.greenruner.genShouldFill(reflect.Map) || nonEmpty || fc.neverNil(v.Type()) {
+			v.Set(reflect.MakeMap(v.Type()))
+			if fc.recursionExhausted(v.Type().Elem()) {
+				fc.trace(v, "empty map, max recursion reached")
+				return
+			}
+			n := fc.takeNonEmpty(fc.greenruner.genCollectionLen(v.Type()), nonEmpty)
+			fc.trace(v, "map with %d elements", n)
+			for i, tries := 0, 0; i < n && tries < mapKeyAttempts; {
+				key := reflect.New(v.Type().Key()).Elem()
+				fc.asciiKey = fc.greenruner.asciiKeys && key.Kind() == reflect.String
+				fc.doGreenRunAt(pathSegment{isKey: true, index: i, attempt: tries}, key, 0)
+				fc.asciiKey = false
+				if v.MapIndex(key).IsValid() {
+					// Duplicate key; try again, unless the key type
+					// seems to have run out of values.
+					tries++
+					continue
+				}
+				i, tries = i+1, 0
+				val := reflect.New(v.Type().Elem()).Elem()
+				fc.doGreenRunAt(pathSegment{key: key}, val, 0)
+				v.SetMapIndex(key, val)
+			}
+			return
+		}
+		fc.setNil(v, "nil map")
+	case reflect.Ptr:
+		if fc.recursionExhausted(v.Type().Elem()) && flags&flagNoNil == 0 {
+			fc.setNil(v, "nil pointer, max recursion reached")
+			return
+		}
+		if fc.greenruner.genShouldFill(reflect.Ptr) || flags&flagNoNil != 0 || fc.neverNil(v.Type()) || fc.greenruner.nonNilPointersTo[v.Type().Elem().Kind()] {
+			if fc.tryAlias(v) {
+				return
+			}
+			fc.trace(v, "filled pointer")
+			v.Set(reflect.New(v.Type().Elem()))
+			fc.doGreenRun(v.Elem(), 0)
+			// Only once filled, so that the values reached from it can't
+			// alias it and make a cycle.
+			if fc.greenruner.aliasChance > 0 {
+				if fc.allocated == nil {
+					fc.allocated = map[reflect.Type][]reflect.Value{}
+				}
+				fc.allocated[v.Type()] = append(fc.allocated[v.Type()], v.Elem().Addr())
+			}
+			return
+		}
+		fc.setNil(v, "nil pointer")
+	case reflect.Slice:
+		if fc.greenruner.preserveLength && !v.IsNil() {
+			n := v.Len()
+			fc.elements += n
+			fc.trace(v, "slice with %d elements, length preserved", n)
+			if fc.plainBytes(v.Type().Elem()) {
+				fc.greenruner.r.Read(v.Bytes())
+				return
+			}
+			for i := 0; i < n; i++ {
+				fc.doGreenRunAt(pathSegment{index: i}, v.Index(i), 0)
+			}
+			return
+		}
+		nonEmpty := fc.greenruner.nonEmpty[v.Type()]
+		if fc.greenruner.genShouldFill(reflect.Slice) || nonEmpty || fc.neverNil(v.Type()) {
+			if fc.recursionExhausted(v.Type().Elem()) {
+				fc.trace(v, "empty slice, max recursion reached")
+				v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+				return
+			}
+			n := fc.takeNonEmpty(fc.greenruner.genCollectionLen(v.Type()), nonEmpty)
+			fc.trace(v, "slice with %d elements", n)
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+			if fc.plainBytes(v.Type().Elem()) {
+				fc.greenruner.r.Read(v.Bytes())
+				return
+			}
+			for i := 0; i < n; i++ {
+				fc.doGreenRunAt(pathSegment{index: i}, v.Index(i), 0)
+			}
+			return
+		}
+		fc.setNil(v, "nil slice")
+	case reflect.Array:
+		// Arrays can't be nil, so they are always filled unless a nil
+		// chance is set for them explicitly. The draw is made either way to
+		// keep the values generated for a seed stable.
+		_, zeroable := fc.greenruner.nilChanceFor[reflect.Array]
+		if !fc.greenruner.genShouldFill(reflect.Array) && zeroable {
+			fc.trace(v, "zero array")
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		n := fc.takeElements(v.Len())
+		fc.trace(v, "array with %d of %d elements", n, v.Len())
+		if n < v.Len() {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		for i := 0; i < n; i++ {
+			fc.doGreenRunAt(pathSegment{index: i}, v.Index(i), 0)
+		}
+	case reflect.Struct:
+		var order []int
+		if fc.greenruner.shuffleFields {
+			order = fc.greenruner.r.Perm(v.NumField())
+		}
+		if fc.greenruner.deterministicStreams {
+			parent := fc.greenruner.r
+			defer func() { fc.greenruner.r = parent }()
+		}
+		for j := 0; j < v.NumField(); j++ {
+			i := j
+			if order != nil {
+				i = order[j]
+			}
+			sf := v.Type().Field(i)
+			seg := pathSegment{field: sf.Name}
+			if fc.greenruner.deterministicStreams {
+				fc.greenruner.r = fc.stream(seg)
+			}
+			tag, err := parseFieldTag(sf)
+			if err != nil {
+				fc.path = append(fc.path, seg)
+				fc.fail("%v", err)
+			}
+			if tag.skip || fc.greenruner.skipFields[sf.Name] || fc.greenruner.skipJSONOmitted && sf.Tag.Get("json") == "-" {
+				fc.path = append(fc.path, seg)
+				fc.trace(v.Field(i), "skipped")
+				fc.path = fc.path[:len(fc.path)-1]
+				continue
+			}
+			field := v.Field(i)
+			if !field.CanSet() && fc.greenruner.allowUnexported && field.CanAddr() {
+				field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+			}
+			if tag.hasRange {
+				fc.path = append(fc.path, seg)
+				fc.fillRange(field, sf, tag)
+				fc.path = fc.path[:len(fc.path)-1]
+				continue
+			}
+			if tag.regexp != "" {
+				fc.path = append(fc.path, seg)
+				fc.fillMatching(field, tag)
+				fc.path = fc.path[:len(fc.path)-1]
+				continue
+			}
+			if tag.hasLen {
+				fc.path = append(fc.path, seg)
+				fc.fillLen(field, tag)
+				fc.path = fc.path[:len(fc.path)-1]
+				continue
+			}
+			if fc.greenruner.respectValidateTags {
+				if vtag := parseValidateTag(sf); vtag.any() {
+					fc.path = append(fc.path, seg)
+					filled := fc.fillValidated(field, vtag)
+					fc.path = fc.path[:len(fc.path)-1]
+					if filled {
+						continue
+					}
+				}
+			}
+			fc.doGreenRunAt(seg, field, 0)
+		}
+	case reflect.Chan:
+		if v.Type().ChanDir() == reflect.RecvDir {
+			// We can't send on a receive-only channel, so leave it alone.
+			return
+		}
+		if fc.greenruner.genShouldFill(reflect.Chan) {
+			n := fc.takeElements(fc.greenruner.genElementCount(v.Type()))
+			fc.trace(v, "channel with %d elements", n)
+			// MakeChan insists on a bidirectional type; the result is
+			// assignable to send-only channel types as well.
+			ch := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, v.Type().Elem()), n)
+			for i := 0; i < n; i++ {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				fc.doGreenRunAt(pathSegment{index: i}, elem, 0)
+				ch.Send(elem)
+			}
+			v.Set(ch)
+			return
+		}
+		fc.setNil(v, "nil channel")
+	case reflect.Func:
+		if fc.greenruner.makeFuncs {
+			if fc.greenruner.genShouldFill(reflect.Func) {
+				v.Set(fc.makeFunc(v.Type()))
+				return
+			}
+			fc.setNil(v, "nil func")
+			return
+		}
+		fallthrough
+	case reflect.Interface:
+		impls := fc.greenruner.interfaceImpls[v.Type()]
+		if len(impls) == 0 && v.Kind() == reflect.Interface && v.Type().NumMethod() == 0 {
+			impls = fc.greenruner.anyTypes
+		}
+		if len(impls) > 0 {
+			v.Set(fc.newImpl(impls[fc.pickImpl(v.Type(), len(impls))]))
+			return
+		}
+		if v.Kind() == reflect.Interface && fc.greenruner.nilInterfaces {
+			fc.setNil(v, "nil interface, no implementations")
+			return
+		}
+		fallthrough
+	default:
+		fc.unhandled(v)
 	}
-	v = v.Elem()
-	f.greenrunWithContext(v, flagNoCustomGreenRun)
 }
 
-const (
-	// Do not try to find a custom greenrun function.  Does not apply recursively.
-	flagNoCustomGreenRun uint64 = 1 << iota
-)
-
-func (f *GreenRunner) greenrunWithContext(v reflect.Value, flags uint64) {
-	fc := &greenrunerContext{greenruner: f}
-	fc.doGreenRun(v, flags)
+// unhandled gives OnUnhandled a chance to fill v, and fails if it doesn't.
+func (fc *greenrunerContext) unhandled(v reflect.Value) {
+	if fn := fc.greenruner.onUnhandled; fn != nil && fn(v, Continue{fc: fc, Rand: fc.greenruner.r}) {
+		return
+	}
+	fc.fail("can't handle %v", v.Type())
 }
 
-// greenrunerContext carries context about a single greenruning run, which lets GreenRunner
-// be thread-safe.
-type greenrunerContext struct {
-	greenruner   *GreenRunner
-	curDepth int
-}
+// nonZeroAttempts is how many values are generated, with NonZero, in search
+// of one that isn't zero before keeping a zero value.
+const nonZeroAttempts = 100
 
-func (fc *greenrunerContext) doGreenRun(v reflect.Value, flags uint64) {
-	if fc.curDepth >= fc.greenruner.maxDepth {
+// fillPrimitive fills v, of a kind in fillFuncMap, using the fill function fn
+// for its kind unless an edge case or a KindFuncs function is used instead.
+func (fc *greenrunerContext) fillPrimitive(v reflect.Value, fn func(reflect.Value, *greenrunerContext)) {
+	if fc.tryEdgeCase(v) {
 		return
 	}
-	fc.curDepth++
-	defer func() { fc.curDepth-- }()
-
-	if !v.CanSet() {
+	if kindFn, ok := fc.greenruner.kindFuncs[v.Kind()]; ok {
+		kindFn(v, fc.greenruner.r)
 		return
 	}
+	fn(v, fc)
+}
 
-	if flags&flagNoCustomGreenRun == 0 {
-		// Check for both pointer and non-pointer custom functions.
-		if v.CanAddr() && fc.tryCustom(v.Addr()) {
-			return
+// tryEdgeCase sets the numeric value v to one of the boundary values for its
+// type, with the probability set by EdgeCaseChance, and reports whether it
+// did.
+func (fc *greenrunerContext) tryEdgeCase(v reflect.Value) bool {
+	f := fc.greenruner
+	if f.edgeCaseChance == 0 {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f.r.Float64() >= f.edgeCaseChance {
+			return false
 		}
-		if fc.tryCustom(v) {
-			return
+		lo, hi := f.intBounds(v.Type())
+		edges := []int64{lo, hi}
+		for _, n := range []int64{-1, 0, 1} {
+			if lo < n && n < hi {
+				edges = append(edges, n)
+			}
+		}
+		v.SetInt(edges[f.r.Intn(len(edges))])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Kind() == reflect.Uintptr && !f.rawPointers || f.r.Float64() >= f.edgeCaseChance {
+			return false
+		}
+		lo, hi := f.uintBounds(v.Type())
+		edges := []uint64{lo, hi}
+		if lo < 1 && 1 < hi {
+			edges = append(edges, 1)
+		}
+		v.SetUint(edges[f.r.Intn(len(edges))])
+	case reflect.Float32, reflect.Float64:
+		if f.r.Float64() >= f.edgeCaseChance {
+			return false
+		}
+		smallest, largest := math.SmallestNonzeroFloat64, math.MaxFloat64
+		if v.Kind() == reflect.Float32 {
+			smallest, largest = math.SmallestNonzeroFloat32, math.MaxFloat32
 		}
+		edges := []float64{
+			0, math.Copysign(0, -1), math.Inf(1), math.Inf(-1), math.NaN(),
+			smallest, -smallest, largest, -largest,
+		}
+		v.SetFloat(edges[f.r.Intn(len(edges))])
+	default:
+		return false
 	}
+	return true
+}
 
-	if fn, ok := fillFuncMap[v.Kind()]; ok {
-		fn(v, fc.greenruner.r)
-		return
+// plainBytes reports whether values of the byte type t can be filled in bulk
+// with random bytes, without going through doGreenRun for each one. Only t's
+// kind matters, so slices of defined byte types, and defined slice types
+// such as json.RawMessage, qualify too, unless they have custom functions,
+// are restricted by Enum, or may be edge cases.
+func (fc *greenrunerContext) plainBytes(t reflect.Type) bool {
+	if t.Kind() != reflect.Uint8 || fc.greenruner.hasIntRange || fc.greenruner.nonZero || fc.greenruner.edgeCaseChance > 0 {
+		return false
 	}
-	switch v.Kind() {
-	case reflect.Map:
-		if fc.greenruner.genShouldFill() {
-			v.Set(reflect.MakeMap(v.Type()))
-			n := fc.greenruner.genElementCount()
-			for i := 0; i < n; i++ {
-				key := reflect.New(v.Type().Key()).Elem()
-				fc.doGreenRun(key, 0)
-				val := reflect.New(v.Type().Elem()).Elem()
-				fc.doGreenRun(val, 0)
-				v.SetMapIndex(key, val)
-			}
-			return
+	if _, ok := fc.greenruner.enums[t]; ok {
+		return false
+	}
+	if _, ok := fc.greenruner.kindFuncs[reflect.Uint8]; ok {
+		return false
+	}
+	if t.Implements(interfaceType) || reflect.PtrTo(t).Implements(interfaceType) {
+		return false
+	}
+	for _, m := range []greenrunFuncMap{fc.greenruner.greenrunFuncs, fc.greenruner.defaultGreenRunFuncs} {
+		if _, ok := m[t]; ok {
+			return false
 		}
-		v.Set(reflect.Zero(v.Type()))
-	case reflect.Ptr:
-		if fc.greenruner.genShouldFill() {
-			v.Set(reflect.New(v.Type().Elem()))
-			fc.doGreenRun(v.Elem(), 0)
-			return
+		if _, ok := m[reflect.PtrTo(t)]; ok {
+			return false
 		}
-		v.Set(reflect.Zero(v.Type()))
-	case reflect.Slice:
-		if fc.greenruner.genShouldFill() {
-			n := fc.greenruner.genElementCount()
-			v.Set(reflect.MakeSlice(v.Type(), n, n))
-			for i := 0; i < n; i++ {
-				fc.doGreenRun(v.Index(i), 0)
+	}
+	return true
+}
+
+// pickImpl returns the index of a randomly picked implementation among the n
+// candidates for the interface type t, according to their weights, if any.
+func (fc *greenrunerContext) pickImpl(t reflect.Type, n int) int {
+	weights, ok := fc.greenruner.implWeights[t]
+	if !ok {
+		return fc.greenruner.r.Intn(n)
+	}
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		fc.fail("all implementations of %v have weight 0", t)
+	}
+	k := fc.greenruner.r.Intn(total)
+	for i, w := range weights {
+		if k < w {
+			return i
+		}
+		k -= w
+	}
+	panic("unreachable")
+}
+
+// newImpl returns a newly greenruned value of type t. If t is a pointer type,
+// the pointer is always allocated.
+func (fc *greenrunerContext) newImpl(t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.Ptr {
+		p := reflect.New(t.Elem())
+		fc.doGreenRun(p.Elem(), 0)
+		return p
+	}
+	impl := reflect.New(t).Elem()
+	fc.doGreenRun(impl, 0)
+	return impl
+}
+
+// makeFunc returns a function of type t that ignores its arguments and
+// returns newly greenruned values for each of its results, or zero values
+// once the run's context is done.
+func (fc *greenrunerContext) makeFunc(t reflect.Type) reflect.Value {
+	var calls uint64
+	return reflect.MakeFunc(t, func([]reflect.Value) (out []reflect.Value) {
+		zero := func() []reflect.Value {
+			out := make([]reflect.Value, t.NumOut())
+			for i := range out {
+				out[i] = reflect.New(t.Out(i)).Elem()
 			}
-			return
+			return out
 		}
-		v.Set(reflect.Zero(v.Type()))
-	case reflect.Array:
-		if fc.greenruner.genShouldFill() {
-			n := v.Len()
-			for i := 0; i < n; i++ {
-				fc.doGreenRun(v.Index(i), 0)
+		if fc.ctx != nil && fc.ctx.Err() != nil {
+			return zero()
+		}
+		// This runs after the run that created it is over, so nothing
+		// upstream will recover a failure.
+		defer func() {
+			if r := recover(); r != nil {
+				if gp, ok := r.(greenrunPanic); ok {
+					if fc.ctx != nil && fc.ctx.Err() != nil {
+						out = zero()
+						return
+					}
+					panic(gp.err)
+				}
+				panic(r)
 			}
-			return
+		}()
+		if fc.greenruner.deterministicStreams {
+			// Each call gets streams of its own, rather than those of the
+			// same paths in the previous call.
+			calls++
+			seed := fc.streamSeed
+			fc.streamSeed = (&splitMixSource{state: seed + calls}).Uint64()
+			defer func() { fc.streamSeed = seed }()
 		}
-		v.Set(reflect.Zero(v.Type()))
-	case reflect.Struct:
-		for i := 0; i < v.NumField(); i++ {
-			fc.doGreenRun(v.Field(i), 0)
+		out = zero()
+		for i := range out {
+			fc.doGreenRun(out[i], 0)
 		}
-	case reflect.Chan:
-		fallthrough
-	case reflect.Func:
-		fallthrough
-	case reflect.Interface:
-		fallthrough
-	default:
-		panic(fmt.Sprintf("Can't handle %#v", v.Interface()))
-	}
+		return out
+	})
 }
 
 // tryCustom searches for custom handlers, and returns true iff it finds a match
 // and successfully randomizes v.
 func (fc *greenrunerContext) tryCustom(v reflect.Value) bool {
+	if values, ok := fc.greenruner.enums[v.Type()]; ok && v.CanSet() {
+		v.Set(values[fc.greenruner.r.Intn(len(values))])
+		return true
+	}
+
 	// First: see if we have a greenrun function for it.
 	doCustom, ok := fc.greenruner.greenrunFuncs[v.Type()]
 	if !ok {
@@ -297,6 +2137,10 @@ func (fc *greenrunerContext) tryCustom(v reflect.Value) bool {
 		if v.CanInterface() {
 			intf := v.Interface()
 			if greenrunable, ok := intf.(Interface); ok {
+				fc.stats.CustomFuncCalls++
+				if v.Kind() == reflect.Ptr && !v.IsNil() && fc.visit(v) {
+					defer fc.leave(v)
+				}
 				greenrunable.GreenRun(Continue{fc: fc, Rand: fc.greenruner.r})
 				return true
 			}
@@ -323,14 +2167,29 @@ func (fc *greenrunerContext) tryCustom(v reflect.Value) bool {
 			}
 			v.Set(reflect.MakeMap(v.Type()))
 		}
+	case reflect.Slice:
+		if v.IsNil() {
+			if !v.CanSet() {
+				return false
+			}
+			n := fc.takeElements(fc.greenruner.genElementCount(v.Type()))
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+		}
 	default:
 		return false
 	}
 
-	doCustom.Call([]reflect.Value{v, reflect.ValueOf(Continue{
+	fc.stats.CustomFuncCalls++
+	if v.Kind() == reflect.Ptr && fc.visit(v) {
+		defer fc.leave(v)
+	}
+	out := doCustom.Call([]reflect.Value{v, reflect.ValueOf(Continue{
 		fc:   fc,
 		Rand: fc.greenruner.r,
 	})})
+	if len(out) == 1 && !out[0].IsNil() {
+		fc.fail("%w", out[0].Interface().(error))
+	}
 	return true
 }
 
@@ -341,6 +2200,19 @@ type Interface interface {
 	GreenRun(c Continue)
 }
 
+// NeverNiler is implemented by pointer, map and slice types that must never be
+// left nil, because, say, their constructors enforce invariants. The method
+// is only a marker; it is never called. See GreenRunner.NeverNil.
+type NeverNiler interface {
+	NeverNil()
+}
+
+var (
+	interfaceType  = reflect.TypeOf((*Interface)(nil)).Elem()
+	neverNilerType = reflect.TypeOf((*NeverNiler)(nil)).Elem()
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+)
+
 // Continue can be passed to custom greenruning functions to allow them to use
 // the correct source of randomness and to continue greenruning their members.
 type Continue struct {
@@ -352,14 +2224,58 @@ type Continue struct {
 	*rand.Rand
 }
 
-// GreenRun continues greenruning obj. obj must be a pointer.
+// GreenRun continues greenruning obj. obj must be a pointer. If obj is being
+// filled already, for instance because it is the object the calling custom
+// function was given, it is left as-is; use GreenRunNoCustom to fill in such
+// an object.
 func (c Continue) GreenRun(obj interface{}) {
+	if c.fc.split {
+		// Nothing upstream recovers a failure in another goroutine.
+		if err := c.GreenRunE(obj); err != nil {
+			panic(err)
+		}
+		return
+	}
+	c.greenrun(obj)
+}
+
+// GreenRunE is like GreenRun, but returns the error found filling obj, if
+// any, rather than failing the whole run. It is how errors are reported by
+// Continues from Split, which run apart from the call that made them.
+func (c Continue) GreenRunE(obj interface{}) (err error) {
+	// Not all state is restored as a failure unwinds, so restore what
+	// isn't, for the run to go on.
+	path, asciiKey := len(c.fc.path), c.fc.asciiKey
+	defer func() {
+		if r := recover(); r != nil {
+			gp, ok := r.(greenrunPanic)
+			if !ok {
+				panic(r)
+			}
+			c.fc.path, c.fc.asciiKey = c.fc.path[:path], asciiKey
+			err = gp.err
+		}
+	}()
+	c.greenrun(obj)
+	return nil
+}
+
+func (c Continue) greenrun(obj interface{}) {
 	v := reflect.ValueOf(obj)
 	if v.Kind() != reflect.Ptr {
 		panic("needed ptr!")
 	}
-	v = v.Elem()
-	c.fc.doGreenRun(v, 0)
+	if v.IsNil() || !c.fc.visit(v) {
+		return
+	}
+	defer c.fc.leave(v)
+	c.fc.doGreenRun(v.Elem(), 0)
+}
+
+// Fuzz is equivalent to GreenRun. It eases migrating custom functions
+// written for gofuzz.
+func (c Continue) Fuzz(obj interface{}) {
+	c.GreenRun(obj)
 }
 
 // GreenRunNoCustom continues greenruning obj, except that any custom greenrun function for
@@ -375,10 +2291,88 @@ func (c Continue) GreenRunNoCustom(obj interface{}) {
 	c.fc.doGreenRun(v, flagNoCustomGreenRun)
 }
 
-// RandString makes a random string up to 20 characters long. The returned string
-// may include a variety of (valid) UTF-8 encodings.
+// Split returns a Continue with its own source of randomness, seeded from c's,
+// which may be used in another goroutine while c, or other Continues split
+// from it, are used elsewhere. For GreenRunners from NewFromBytes, the data
+// left is instead partitioned: the split Continue takes the second half, and
+// c keeps the first. Its GreenRun fills values in a run of its own that
+// starts where c is, so pointers followed in one aren't known to the other,
+// and each has its own MaxTotalElements budget. Use its GreenRunE to get the
+// errors it finds; its GreenRun panics with them.
+//
+// The values a split Continue generates depend only on the order in which
+// Split is called, not on how the goroutines are scheduled, as long as each
+// split Continue is used by a single goroutine.
+func (c Continue) Split() Continue {
+	g := *c.fc.greenruner
+	if b := g.bytes; b != nil {
+		half := len(b.data) / 2
+		g.bytes = &byteSource{data: b.data[half:]}
+		b.data = b.data[:half]
+		g.r = rand.New(g.bytes)
+	} else {
+		g.r = rand.New(rand.NewSource(c.Rand.Int63()))
+	}
+	fc := &greenrunerContext{
+		greenruner: &g,
+		ctx:        c.fc.ctx,
+		curDepth:   c.fc.curDepth,
+		root:       c.fc.root,
+		path:       append([]pathSegment(nil), c.fc.path...),
+		visited:    map[visitKey]bool{},
+		elements:   c.fc.elements,
+		recursion:  map[reflect.Type]int{},
+		split:      true,
+	}
+	for k := range c.fc.visited {
+		fc.visited[k] = true
+	}
+	for t, n := range c.fc.recursion {
+		fc.recursion[t] = n
+	}
+	return Continue{fc: fc, Rand: g.r}
+}
+
+// Depth returns how many levels deep into the object being greenruned the
+// value being filled in is, as counted against MaxDepth.
+func (c Continue) Depth() int {
+	return c.fc.curDepth
+}
+
+// Path returns the path to the value being filled in within the object being
+// greenruned, e.g. "MyType.Items[2].Name". This lets custom functions for
+// types used in several places behave differently in each.
+func (c Continue) Path() string {
+	return c.fc.pathString()
+}
+
+// Index returns the index of the value being filled in within its slice,
+// array or channel, when GreenRun reached it as an element of one, or -1
+// otherwise. This lets custom functions vary by position.
+func (c Continue) Index() int {
+	if len(c.fc.path) == 0 {
+		return -1
+	}
+	if s := c.fc.path[len(c.fc.path)-1]; s.field == "" && !s.key.IsValid() && !s.isKey {
+		return s.index
+	}
+	return -1
+}
+
+// RandString makes a random string whose length is within the bounds set by
+// StringLen. The returned string may include a variety of (valid) UTF-8
+// encodings.
 func (c Continue) RandString() string {
-	return randString(c.Rand)
+	return c.fc.greenruner.randString(c.Rand)
+}
+
+// RandWords returns n words picked at random from the Wordlist, separated by
+// spaces. It panics if no Wordlist is set.
+func (c Continue) RandWords(n int) string {
+	if len(c.fc.greenruner.words) == 0 {
+		panic("no Wordlist set")
+	}
+	return c.fc.greenruner.randWords(c.Rand, n)
 }
 
 // RandUint64 makes random 64 bit numbers.
@@ -392,26 +2386,202 @@ func (c Continue) RandBool() bool {
 	return randBool(c.Rand)
 }
 
-func greenrunInt(v reflect.Value, r *rand.Rand) {
-	v.SetInt(int64(randUint64(r)))
+// RandElement returns a random index into a collection of n elements, in
+// [0, n). It panics if n <= 0. Like the other Rand methods it draws from the
+// GreenRunner's source of randomness, so results are repeatable for a given
+// seed.
+func (c Continue) RandElement(n int) int {
+	return c.Rand.Intn(n)
+}
+
+// RandFloat64Range returns a random float64 in [min, max). It panics if
+// min > max. Like the other Rand methods it draws from the GreenRunner's
+// source of randomness, so results are repeatable for a given seed.
+func (c Continue) RandFloat64Range(min, max float64) float64 {
+	if min > max {
+		panic("min must be <= max")
+	}
+	return min + c.Rand.Float64()*(max-min)
+}
+
+// SortedKeys returns the keys of the map m in ascending order, so that
+// custom functions can range over a map in a repeatable order. Keys must be
+// of a string, integer, float or bool kind; it panics otherwise.
+func (c Continue) SortedKeys(m interface{}) []reflect.Value {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map {
+		panic("needed map!")
+	}
+	keys := v.MapKeys()
+	var less func(a, b reflect.Value) bool
+	switch v.Type().Key().Kind() {
+	case reflect.String:
+		less = func(a, b reflect.Value) bool { return a.String() < b.String() }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		less = func(a, b reflect.Value) bool { return a.Float() < b.Float() }
+	case reflect.Bool:
+		less = func(a, b reflect.Value) bool { return !a.Bool() && b.Bool() }
+	default:
+		panic(fmt.Sprintf("can't sort keys of type %v", v.Type().Key()))
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	return keys
+}
+
+// RandBytes returns n random bytes.
+func (c Continue) RandBytes(n int) []byte {
+	b := make([]byte, n)
+	c.Rand.Read(b)
+	return b
+}
+
+func greenrunInt(v reflect.Value, fc *greenrunerContext) {
+	f := fc.greenruner
+	if !f.hasIntRange {
+		v.SetInt(int64(randUint64(f.r)))
+		return
+	}
+	lo, hi := f.intBounds(v.Type())
+	v.SetInt(randInt64Range(f.r, lo, hi))
+}
+
+func greenrunUint(v reflect.Value, fc *greenrunerContext) {
+	f := fc.greenruner
+	if !f.hasIntRange {
+		v.SetUint(randUint64(f.r))
+		return
+	}
+	lo, hi := f.uintBounds(v.Type())
+	v.SetUint(randUint64Range(f.r, lo, hi))
+}
+
+// greenrunUintptr leaves v zero unless GreenRunRawPointers is enabled.
+func greenrunUintptr(v reflect.Value, fc *greenrunerContext) {
+	if !fc.greenruner.rawPointers {
+		v.SetUint(0)
+		return
+	}
+	greenrunUint(v, fc)
+}
+
+// intBounds returns the range of values to generate for the signed integer
+// type t: what it can hold, narrowed by IntRange if set.
+func (f *GreenRunner) intBounds(t reflect.Type) (lo, hi int64) {
+	bits := uint(t.Bits())
+	lo, hi = int64(-1)<<(bits-1), int64(^uint64(0)>>(65-bits))
+	if !f.hasIntRange {
+		return lo, hi
+	}
+	clamp := func(n int64) int64 {
+		if n < lo {
+			return lo
+		}
+		if n > hi {
+			return hi
+		}
+		return n
+	}
+	return clamp(f.minInt), clamp(f.maxInt)
+}
+
+// uintBounds returns the range of values to generate for the unsigned
+// integer type t: what it can hold, narrowed by IntRange if set.
+func (f *GreenRunner) uintBounds(t reflect.Type) (lo, hi uint64) {
+	hi = ^uint64(0) >> (64 - uint(t.Bits()))
+	if !f.hasIntRange {
+		return 0, hi
+	}
+	clamp := func(n int64) uint64 {
+		if n < 0 {
+			return 0
+		}
+		if uint64(n) > hi {
+			return hi
+		}
+		return uint64(n)
+	}
+	return clamp(f.minInt), clamp(f.maxInt)
 }
 
-func greenrunUint(v reflect.Value, r *rand.Rand) {
-	v.SetUint(randUint64(r))
+func greenrunFloat(v reflect.Value, fc *greenrunerContext) {
+	f := fc.greenruner
+	bits32 := v.Kind() == reflect.Float32
+	if f.hasFloatRange {
+		// Interpolating, rather than scaling by max-min, avoids overflowing
+		// for ranges wider than the largest float.
+		x := f.r.Float64()
+		v.SetFloat(f.minFloat*(1-x) + f.maxFloat*x)
+		return
+	}
+	switch f.floatMode {
+	case FloatFull, FloatFinite:
+		if f.floatDecimalSafe {
+			// A mantissa in [1, 10) times a power of 10 in [1e-6, 1e20].
+			x := (1 + 9*f.r.Float64()) * math.Pow10(f.r.Intn(27)-6)
+			if randBool(f.r) {
+				x = -x
+			}
+			v.SetFloat(x)
+			return
+		}
+		for {
+			var x float64
+			if bits32 {
+				x = float64(math.Float32frombits(f.r.Uint32()))
+			} else {
+				x = math.Float64frombits(f.r.Uint64())
+			}
+			if f.floatMode == FloatFull || !math.IsInf(x, 0) && !math.IsNaN(x) {
+				v.SetFloat(x)
+				return
+			}
+		}
+	}
+	if bits32 {
+		v.SetFloat(float64(f.r.Float32()))
+		return
+	}
+	v.SetFloat(f.r.Float64())
 }
 
 func greenrunTime(t *time.Time, c Continue) {
+	f := c.fc.greenruner
+	loc := time.UTC
+	if f.timeLocation != nil {
+		loc = f.timeLocation
+	}
+	if f.hasTimeRange {
+		sec := randInt64Range(c.Rand, f.minTime.Unix(), f.maxTime.Unix())
+		*t = time.Unix(sec, c.Rand.Int63n(int64(time.Second)))
+		// Only the seconds at either end can stray outside of the range.
+		if t.Before(f.minTime) {
+			*t = f.minTime
+		} else if t.After(f.maxTime) {
+			*t = f.maxTime
+		}
+		*t = t.Round(0).In(loc)
+		return
+	}
 	var sec, nsec int64
 	// Allow for about 1000 years of random time values, which keeps things
 	// like JSON parsing reasonably happy.
 	sec = c.Rand.Int63n(1000 * 365 * 24 * 60 * 60)
 	c.GreenRun(&nsec)
-	*t = time.Unix(sec, nsec)
+	*t = time.Unix(sec, nsec).In(loc)
+}
+
+func greenrunDuration(d *time.Duration, c Continue) {
+	f := c.fc.greenruner
+	*d = time.Duration(randInt64Range(c.Rand, int64(f.minDuration), int64(f.maxDuration)))
 }
 
-var fillFuncMap = map[reflect.Kind]func(reflect.Value, *rand.Rand){
-	reflect.Bool: func(v reflect.Value, r *rand.Rand) {
-		v.SetBool(randBool(r))
+var fillFuncMap = map[reflect.Kind]func(reflect.Value, *greenrunerContext){
+	reflect.Bool: func(v reflect.Value, fc *greenrunerContext) {
+		v.SetBool(randBool(fc.greenruner.r))
 	},
 	reflect.Int:     greenrunInt,
 	reflect.Int8:    greenrunInt,
@@ -423,27 +2593,55 @@ var fillFuncMap = map[reflect.Kind]func(reflect.Value, *rand.Rand){
 	reflect.Uint16:  greenrunUint,
 	reflect.Uint32:  greenrunUint,
 	reflect.Uint64:  greenrunUint,
-	reflect.Uintptr: greenrunUint,
-	reflect.Float32: func(v reflect.Value, r *rand.Rand) {
-		v.SetFloat(float64(r.Float32()))
-	},
-	reflect.Float64: func(v reflect.Value, r *rand.Rand) {
-		v.SetFloat(r.Float64())
+	reflect.Uintptr: greenrunUintptr,
+	reflect.Float32: greenrunFloat,
+	reflect.Float64: greenrunFloat,
+	reflect.Complex64: func(v reflect.Value, fc *greenrunerContext) {
+		fc.unhandled(v)
 	},
-	reflect.Complex64: func(v reflect.Value, r *rand.Rand) {
-		panic("unimplemented")
+	reflect.Complex128: func(v reflect.Value, fc *greenrunerContext) {
+		fc.unhandled(v)
 	},
-	reflect.Complex128: func(v reflect.Value, r *rand.Rand) {
-		panic("unimplemented")
-	},
-	reflect.String: func(v reflect.Value, r *rand.Rand) {
-		v.SetString(randString(r))
+	reflect.String: func(v reflect.Value, fc *greenrunerContext) {
+		f := fc.greenruner
+		if fc.asciiKey {
+			v.SetString(f.randStringIn(f.r, asciiCharset, f.minStringLen, f.maxStringLen))
+			return
+		}
+		v.SetString(f.randString(f.r))
 	},
-	reflect.UnsafePointer: func(v reflect.Value, r *rand.Rand) {
-		panic("unimplemented")
+	reflect.UnsafePointer: func(v reflect.Value, fc *greenrunerContext) {
+		if !fc.greenruner.rawPointers {
+			v.SetPointer(nil)
+			return
+		}
+		p := new(uint64)
+		*p = randUint64(fc.greenruner.r)
+		v.SetPointer(unsafe.Pointer(p))
 	},
 }
 
+// decimalSafeFloat replaces the value of the float v, if it isn't 0 or of a
+// magnitude in [1e-6, 1e21), by the nearest value that is, counting NaN as
+// 0, for FloatDecimalSafe. encoding/json marshals those in plain decimal.
+func decimalSafeFloat(v reflect.Value) {
+	if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+		return
+	}
+	x := v.Float()
+	max := math.Nextafter(1e21, 0)
+	if v.Kind() == reflect.Float32 {
+		x = float64(float32(x))
+		max = float64(math.Nextafter32(1e21, 0))
+	}
+	switch a := math.Abs(x); {
+	case math.IsNaN(x) || a < 1e-6:
+		v.SetFloat(0)
+	case a >= 1e21:
+		v.SetFloat(math.Copysign(max, x))
+	}
+}
+
 // randBool returns true or false randomly.
 func randBool(r *rand.Rand) bool {
 	if r.Int()&1 == 1 {
@@ -457,10 +2655,54 @@ type charRange struct {
 }
 
 // choose returns a random unicode character from the given range, using the
-// given randomness source.
-func (r *charRange) choose(rand *rand.Rand) rune {
-	count := int64(r.last - r.first)
-	return r.first + rune(rand.Int63n(count))
+// given randomness source. Unless assigned is set, the last character of the
+// range is never chosen, which keeps the values generated for a seed stable.
+// If it is set, only assigned characters are returned, so the range must
+// contain at least one.
+func (r *charRange) choose(rand *rand.Rand, assigned bool) rune {
+	if !assigned {
+		count := int64(r.last - r.first)
+		if count == 0 {
+			return r.first
+		}
+		return r.first + rune(rand.Int63n(count))
+	}
+	count := int64(r.last-r.first) + 1
+	if count == 1 {
+		return r.first
+	}
+	for tries := 0; tries < chooseAttempts; tries++ {
+		if c := r.first + rune(rand.Int63n(count)); assignedRune(c) {
+			return c
+		}
+	}
+	// Assigned characters are rare in this range, so settle for the first.
+	for c := r.first; ; c++ {
+		if assignedRune(c) {
+			return c
+		}
+	}
+}
+
+// chooseAttempts is how many runes charRange.choose picks at random in search
+// of an assigned one.
+const chooseAttempts = 100
+
+// assignedRune reports whether c is a valid, assigned Unicode character other
+// than the replacement character.
+func assignedRune(c rune) bool {
+	return utf8.ValidRune(c) && c != utf8.RuneError &&
+		unicode.In(c, unicode.L, unicode.M, unicode.N, unicode.P, unicode.S, unicode.Z, unicode.C)
+}
+
+// hasAssignedRune reports whether any rune in [first, last] is assigned.
+func hasAssignedRune(first, last rune) bool {
+	for c := first; c <= last && c <= unicode.MaxRune; c++ {
+		if assignedRune(c) {
+			return true
+		}
+	}
+	return false
 }
 
 var unicodeRanges = []charRange{
@@ -469,15 +2711,99 @@ var unicodeRanges = []charRange{
 	{'\u4e00', '\u9fff'}, // Common CJK (even longer encodings)
 }
 
-// randString makes a random string up to 20 characters long. The returned string
-// may include a variety of (valid) UTF-8 encodings.
-func randString(r *rand.Rand) string {
-	n := r.Intn(20)
-	runes := make([]rune, n)
-	for i := range runes {
-		runes[i] = unicodeRanges[r.Intn(len(unicodeRanges))].choose(r)
+// randString makes a random string whose length in runes is within the
+// bounds set by StringLen. The returned string may include a variety of
+// (valid) UTF-8 encodings.
+func (f *GreenRunner) randString(r *rand.Rand) string {
+	return f.randStringLen(r, f.minStringLen, f.maxStringLen)
+}
+
+// randStringLen is like randString, except that the string has between min
+// and max runes, or words, inclusive.
+func (f *GreenRunner) randStringLen(r *rand.Rand, min, max int) string {
+	n := min
+	if max > min {
+		n += r.Intn(max - min + 1)
+	}
+	if len(f.words) > 0 {
+		return truncateString(f.randWords(r, n), f.maxStringBytes)
+	}
+	return f.randRunes(r, f.charset, n)
+}
+
+// asciiCharset is the charset used for map keys when ASCIIKeys is enabled.
+var asciiCharset = []charRange{{' ', '~'}}
+
+// randStringIn is like randStringLen, except that the runes are drawn from
+// charset, and the wordlist is ignored.
+func (f *GreenRunner) randStringIn(r *rand.Rand, charset []charRange, min, max int) string {
+	n := min
+	if max > min {
+		n += r.Intn(max - min + 1)
+	}
+	return f.randRunes(r, charset, n)
+}
+
+// randRunes returns a string of n runes drawn from charset, honoring
+// PrintableStrings and MaxStringBytes.
+func (f *GreenRunner) randRunes(r *rand.Rand, charset []charRange, n int) string {
+	runes := make([]rune, 0, n)
+	for i := 0; i < n; i++ {
+		c := charset[r.Intn(len(charset))].choose(r, f.assignedRunes)
+		for tries := 1; f.printableStrings && !unicode.IsPrint(c) && tries < printableAttempts; tries++ {
+			c = charset[r.Intn(len(charset))].choose(r, f.assignedRunes)
+		}
+		if f.printableStrings && !unicode.IsPrint(c) {
+			continue
+		}
+		runes = append(runes, c)
+	}
+	return truncateString(string(runes), f.maxStringBytes)
+}
+
+// truncateString shortens s, at a rune boundary, to at most max bytes, unless
+// max is 0.
+func truncateString(s string, max int) string {
+	if max == 0 || len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// randWords returns n words picked at random from the Wordlist, separated by
+// spaces.
+func (f *GreenRunner) randWords(r *rand.Rand, n int) string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = f.words[r.Intn(len(f.words))]
+	}
+	return strings.Join(words, " ")
+}
+
+// printableAttempts is how many runes are picked, with PrintableStrings, in
+// search of a printable one before leaving it out of the string.
+const printableAttempts = 100
+
+// randInt64Range returns a random number in [min, max].
+func randInt64Range(r *rand.Rand, min, max int64) int64 {
+	n := randUint64(r)
+	// The span wraps to 0 when the range covers all of int64.
+	if span := uint64(max-min) + 1; span != 0 {
+		n %= span
+	}
+	return min + int64(n)
+}
+
+// randUint64Range returns a random number in [min, max].
+func randUint64Range(r *rand.Rand, min, max uint64) uint64 {
+	n := randUint64(r)
+	if span := max - min + 1; span != 0 {
+		n %= span
 	}
-	return string(runes)
+	return min + n
 }
 
 // randUint64 makes random 64 bit numbers.
@@ -17,9 +17,26 @@ limitations under the License.
 package greenrun
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"os"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
 )
 
 func TestGreenRun_basic(t *testing.T) {
@@ -42,7 +59,7 @@ func TestGreenRun_basic(t *testing.T) {
 
 	failed := map[string]int{}
 	for i := 0; i < 10; i++ {
-		New().GreenRun(obj)
+		New().GreenRunRawPointers(true).GreenRun(obj)
 
 		if n, v := "i", obj.I; v == 0 {
 			failed[n] = failed[n] + 1
@@ -364,7 +381,7 @@ func TestGreenRun_noCustom(t *testing.T) {
 			inner.Str = testPhrase
 		},
 	)
-	c := Continue{fc: &greenrunnerContext{greenrunner: f}, Rand: f.r}
+	c := Continue{fc: &greenrunerContext{greenruner: f}, Rand: f.r}
 
 	// GreenRunner.GreenRun()
 	obj1 := Outer{}
@@ -470,3 +487,3058 @@ func TestGreenRun_Maxdepth(t *testing.T) {
 		}
 	}
 }
+
+func TestGreenRun_chan(t *testing.T) {
+	obj := &struct {
+		A chan string
+		B chan<- int
+		C <-chan int
+	}{}
+
+	tryGreenRun(t, New(), obj, func() (int, bool) {
+		if obj.A == nil {
+			return 1, false
+		}
+		if cap(obj.A) == 0 || len(obj.A) != cap(obj.A) {
+			return 2, false
+		}
+		for len(obj.A) > 0 {
+			if <-obj.A == "" {
+				return 3, false
+			}
+		}
+		if obj.B == nil {
+			return 4, false
+		}
+		if len(obj.B) != cap(obj.B) {
+			return 5, false
+		}
+		if obj.C != nil {
+			return 6, false
+		}
+		return 7, true
+	})
+}
+
+func TestGreenRun_funcs(t *testing.T) {
+	obj := &struct {
+		A func(int) string
+		B func() (int, *string)
+		C func()
+	}{}
+
+	f := New().NilChance(0).GreenRunFuncs(true)
+	tryGreenRun(t, f, obj, func() (int, bool) {
+		if obj.A == nil || obj.B == nil || obj.C == nil {
+			return 1, false
+		}
+		if obj.A(1) == "" {
+			return 2, false
+		}
+		if i, s := obj.B(); i == 0 || s == nil {
+			return 3, false
+		}
+		obj.C()
+		return 4, true
+	})
+
+	// Once the context is done, generated functions return zero values.
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := f.GreenRunContext(ctx, obj); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cancel()
+	if i, s := obj.B(); i != 0 || s != nil {
+		t.Errorf("expected zero values once the context is done, got %v, %v", i, s)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic when func values are not enabled")
+		}
+	}()
+	New().GreenRun(obj)
+}
+
+type testShape interface {
+	Area() int
+}
+
+type testSquare struct {
+	Side int
+}
+
+func (s testSquare) Area() int { return s.Side * s.Side }
+
+type testRect struct {
+	W, H int
+}
+
+func (r *testRect) Area() int { return r.W * r.H }
+
+func TestGreenRun_interfaceImpls(t *testing.T) {
+	obj := &struct {
+		A testShape
+		B []testShape
+	}{}
+
+	f := New().NilChance(0).InterfaceImpls(reflect.TypeOf((*testShape)(nil)).Elem(), testSquare{}, &testRect{})
+	sawSquare, sawRect := false, false
+	tryGreenRun(t, f, obj, func() (int, bool) {
+		switch s := obj.A.(type) {
+		case testSquare:
+			if s.Side == 0 {
+				return 1, false
+			}
+			sawSquare = true
+		case *testRect:
+			if s == nil || s.W == 0 || s.H == 0 {
+				return 1, false
+			}
+			sawRect = true
+		default:
+			return 1, false
+		}
+		for _, s := range obj.B {
+			if s == nil {
+				return 2, false
+			}
+		}
+		return 3, sawSquare && sawRect
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for an interface without implementations")
+		}
+	}()
+	New().GreenRun(obj)
+}
+
+func TestGreenRun_StringLen(t *testing.T) {
+	f := New().StringLen(3, 5)
+	c := Continue{fc: &greenrunerContext{greenruner: f}, Rand: f.r}
+	for i := 0; i < 100; i++ {
+		var s string
+		f.GreenRun(&s)
+		if n := utf8.RuneCountInString(s); n < 3 || n > 5 {
+			t.Errorf("expected 3 to 5 runes, got %v in %q", n, s)
+		}
+		if n := utf8.RuneCountInString(c.RandString()); n < 3 || n > 5 {
+			t.Errorf("expected 3 to 5 runes from RandString, got %v", n)
+		}
+	}
+
+	f.StringLen(7, 7)
+	var s string
+	f.GreenRun(&s)
+	if n := utf8.RuneCountInString(s); n != 7 {
+		t.Errorf("expected 7 runes, got %v in %q", n, s)
+	}
+}
+
+func TestGreenRun_Charset(t *testing.T) {
+	f := New().StringLen(10, 10).Charset([2]rune{'a', 'c'}, [2]rune{'x', 'x'})
+	c := Continue{fc: &greenrunerContext{greenruner: f}, Rand: f.r}
+	for i := 0; i < 100; i++ {
+		var s string
+		f.GreenRun(&s)
+		for _, r := range s + c.RandString() {
+			if (r < 'a' || r > 'c') && r != 'x' {
+				t.Errorf("unexpected rune %q", r)
+			}
+		}
+	}
+
+	f.ASCIICharset()
+	for i := 0; i < 100; i++ {
+		var s string
+		f.GreenRun(&s)
+		for _, r := range s {
+			if r < ' ' || r > '~' {
+				t.Errorf("unexpected non-ASCII rune %q", r)
+			}
+		}
+	}
+
+	for _, ranges := range [][][2]rune{{}, {{'z', 'a'}}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic for ranges %q", ranges)
+				}
+			}()
+			New().Charset(ranges...)
+		}()
+	}
+}
+
+func TestGreenRun_skipTag(t *testing.T) {
+	type Inner struct {
+		S string
+	}
+	obj := &struct {
+		A int    `greenrun:"-"`
+		B string `greenrun:"-"`
+		C Inner  `greenrun:"-"`
+		D *Inner `greenrun:"-"`
+		E func() `greenrun:"-"`
+		F int
+	}{}
+	preset := &Inner{S: "preset"}
+
+	f := New().NilChance(0)
+	tryGreenRun(t, f, obj, func() (int, bool) {
+		obj.B = "kept"
+		obj.D = preset
+		f.GreenRun(obj)
+		if obj.A != 0 || obj.B != "kept" {
+			return 1, false
+		}
+		if obj.C.S != "" {
+			return 2, false
+		}
+		if obj.D != preset || obj.D.S != "preset" {
+			return 3, false
+		}
+		if obj.E != nil {
+			return 4, false
+		}
+		return 5, obj.F != 0
+	})
+}
+
+func TestGreenRun_rangeTag(t *testing.T) {
+	obj := &struct {
+		Port   uint16  `greenrun:"range=1:65535"`
+		Weight int     `greenrun:"range=0:100"`
+		Offset int8    `greenrun:"range=-3:-1"`
+		Ratio  float64 `greenrun:"range=-0.5:0.5"`
+		Exact  uint    `greenrun:"range=7:7"`
+		Wide   float64 `greenrun:"range=-1.7e308:1.7e308"`
+	}{}
+
+	f := New()
+	for i := 0; i < 100; i++ {
+		f.GreenRun(obj)
+		if obj.Port < 1 {
+			t.Errorf("Port out of range: %v", obj.Port)
+		}
+		if obj.Weight < 0 || obj.Weight > 100 {
+			t.Errorf("Weight out of range: %v", obj.Weight)
+		}
+		if obj.Offset < -3 || obj.Offset > -1 {
+			t.Errorf("Offset out of range: %v", obj.Offset)
+		}
+		if obj.Ratio < -0.5 || obj.Ratio > 0.5 {
+			t.Errorf("Ratio out of range: %v", obj.Ratio)
+		}
+		if obj.Exact != 7 {
+			t.Errorf("Exact out of range: %v", obj.Exact)
+		}
+		if obj.Wide < -1.7e308 || obj.Wide > 1.7e308 {
+			t.Errorf("Wide out of range: %v", obj.Wide)
+		}
+	}
+
+	for _, bad := range []interface{}{
+		&struct {
+			A int `greenrun:"range=1"`
+		}{},
+		&struct {
+			A int `greenrun:"range=5:1"`
+		}{},
+		&struct {
+			A uint8 `greenrun:"range=0:300"`
+		}{},
+		&struct {
+			A string `greenrun:"range=0:1"`
+		}{},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("expected a panic for %T", bad)
+				} else if !strings.Contains(fmt.Sprint(r), ".A") {
+					t.Errorf("expected the panic to name the field, got %v", r)
+				}
+			}()
+			f.GreenRun(bad)
+		}()
+	}
+}
+
+func TestGreenRun_IntRange(t *testing.T) {
+	obj := &struct {
+		I   int
+		I8  int8
+		U   uint
+		U8  uint8
+		U16 uint16
+	}{}
+
+	f := New().IntRange(-10, 300)
+	for i := 0; i < 100; i++ {
+		f.GreenRun(obj)
+		if obj.I < -10 || obj.I > 300 {
+			t.Errorf("I out of range: %v", obj.I)
+		}
+		if obj.I8 < -10 {
+			t.Errorf("I8 out of range: %v", obj.I8)
+		}
+		if obj.U > 300 || obj.U16 > 300 {
+			t.Errorf("U or U16 out of range: %v, %v", obj.U, obj.U16)
+		}
+	}
+
+	// Ranges outside of what a kind can hold clamp to the nearest bound.
+	f.IntRange(1000, 2000)
+	f.GreenRun(obj)
+	if obj.I8 != 127 || obj.U8 != 255 {
+		t.Errorf("expected clamped values, got %v and %v", obj.I8, obj.U8)
+	}
+	f.IntRange(-2000, -1000)
+	f.GreenRun(obj)
+	if obj.I8 != -128 || obj.U != 0 {
+		t.Errorf("expected clamped values, got %v and %v", obj.I8, obj.U)
+	}
+}
+
+func TestGreenRunE(t *testing.T) {
+	type Inner struct {
+		Items []struct {
+			C complex64
+		}
+	}
+	type Outer struct {
+		In Inner
+	}
+
+	f := New().NilChance(0).NumElements(1, 1)
+	var obj Outer
+	err := f.GreenRunE(&obj)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if e, a := "greenrun: can't handle complex64 at Outer.In.Items[0].C", err.Error(); e != a {
+		t.Errorf("expected %q, got %q", e, a)
+	}
+
+	if err := f.GreenRunE(obj); err == nil {
+		t.Errorf("expected an error for a non-pointer")
+	}
+
+	var m map[string]func()
+	if err := f.GreenRunE(&m); err == nil || !strings.Contains(err.Error(), `can't handle func() at ["`) {
+		t.Errorf("expected an error naming a map value, got %v", err)
+	}
+
+	var ok struct {
+		A int
+		B []string
+	}
+	if err := f.GreenRunE(&ok); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected GreenRun to panic")
+		} else if _, ok := r.(error); !ok {
+			t.Errorf("expected GreenRun to panic with an error, got %#v", r)
+		}
+	}()
+	f.GreenRun(&obj)
+}
+
+func TestGreenRun_visited(t *testing.T) {
+	type Node struct {
+		Val  int
+		Next *Node
+	}
+
+	calls := 0
+	f := New().NilChance(0).MaxDepth(1000).Funcs(
+		func(n *Node, c Continue) {
+			calls++
+			// Handing n back must not recurse into this func again.
+			c.GreenRun(n)
+			c.GreenRunNoCustom(&n.Val)
+		},
+	)
+	var n Node
+	f.GreenRun(&n)
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %v", calls)
+	}
+	if n.Val == 0 {
+		t.Errorf("expected Val to be set")
+	}
+}
+
+func TestGreenRun_visitedAddressReuse(t *testing.T) {
+	// The default time.Time function hands a local variable to
+	// Continue.GreenRun. Once freed, its address is reused, which must not
+	// make later ones look filled already.
+	times := make([]time.Time, 200000)
+	New().PreserveLength(true).GreenRun(&times)
+	zeros := 0
+	for _, tm := range times {
+		if tm.Nanosecond() == 0 {
+			zeros++
+		}
+	}
+	if zeros > 0 {
+		t.Errorf("expected every time to have nanoseconds, got %d without", zeros)
+	}
+
+	type item struct {
+		N *int
+	}
+	items := make([]item, 2000)
+	f := New().NilChance(0).PreserveLength(true).Funcs(func(it *item, c Continue) {
+		runtime.GC()
+		n := new(int)
+		c.GreenRun(n)
+		it.N = n
+	})
+	f.GreenRun(&items)
+	zeros = 0
+	for _, it := range items {
+		if *it.N == 0 {
+			zeros++
+		}
+	}
+	if zeros > 1 {
+		t.Errorf("expected every item to be filled, got %d zero", zeros)
+	}
+}
+
+type depthNode struct {
+	Next *depthNode
+}
+
+func TestGreenRun_OnMaxDepth(t *testing.T) {
+	var paths []string
+	f := New().NilChance(0).MaxDepth(5).OnMaxDepth(func(path string) {
+		paths = append(paths, path)
+	})
+
+	var obj depthNode
+	f.GreenRun(&obj)
+	if e, a := []string{"depthNode.Next.Next.Next"}, paths; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	paths = nil
+	f.MaxDepth(100)
+	var s struct{ A, B int }
+	f.GreenRun(&s)
+	if len(paths) != 0 {
+		t.Errorf("expected no calls, got %v", paths)
+	}
+}
+
+func TestGreenRun_bytes(t *testing.T) {
+	obj := &struct {
+		A []byte
+	}{}
+
+	f := New().NilChance(0).NumElements(100, 100)
+	tryGreenRun(t, f, obj, func() (int, bool) {
+		if len(obj.A) != 100 {
+			return 1, false
+		}
+		zeros := 0
+		for _, b := range obj.A {
+			if b == 0 {
+				zeros++
+			}
+		}
+		return 2, zeros < 10
+	})
+
+	f.NilChance(1)
+	f.GreenRun(obj)
+	if obj.A != nil {
+		t.Errorf("expected nil, got %v", obj.A)
+	}
+
+	// Custom functions for bytes are still honored.
+	f.NilChance(0).NumElements(3, 3).Funcs(func(b *byte, c Continue) { *b = 7 })
+	f.GreenRun(obj)
+	if e, a := []byte{7, 7, 7}, obj.A; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+}
+
+func BenchmarkGreenRun_bytes(b *testing.B) {
+	f := New().NilChance(0).NumElements(64<<10, 64<<10)
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		f.GreenRun(&buf)
+	}
+}
+
+func BenchmarkGreenRun_bytesElementwise(b *testing.B) {
+	// A full IntRange gives the same distribution, but sends every byte
+	// through the generic per-element path.
+	f := New().NilChance(0).NumElements(64<<10, 64<<10).IntRange(0, 255)
+	var buf []byte
+	for i := 0; i < b.N; i++ {
+		f.GreenRun(&buf)
+	}
+}
+
+// benchmarkObject is a type with a mix of scalar fields and collections.
+type benchmarkObject struct {
+	ID    int64
+	Name  string
+	Score float64
+	Tags  []string
+	Attrs map[string]string
+	Next  *benchmarkObject
+	Inner struct {
+		A, B int
+		C    []int
+	}
+}
+
+func BenchmarkGreenRun_object(b *testing.B) {
+	f := New().NumElements(5, 10).MaxDepth(5)
+	var obj benchmarkObject
+	for i := 0; i < b.N; i++ {
+		f.GreenRun(&obj)
+	}
+}
+
+func BenchmarkGreenRun_objectLeavesOnly(b *testing.B) {
+	f := New().NumElements(5, 10).MaxDepth(5).LeavesOnly(true)
+	var obj benchmarkObject
+	for i := 0; i < b.N; i++ {
+		f.GreenRun(&obj)
+	}
+}
+
+func TestGreenRun_LeavesOnly(t *testing.T) {
+	f := New().NilChance(0).StringLen(1, 10).LeavesOnly(true)
+	for i := 0; i < 20; i++ {
+		var obj benchmarkObject
+		f.GreenRun(&obj)
+		if obj.ID == 0 || obj.Name == "" || obj.Inner.A == 0 {
+			t.Fatalf("expected scalar fields to be filled, got %+v", obj)
+		}
+		if obj.Tags != nil || obj.Attrs != nil || obj.Next != nil || obj.Inner.C != nil {
+			t.Fatalf("expected collections and pointers to be nil, got %+v", obj)
+		}
+	}
+
+	var p *benchmarkObject
+	f.GreenRun(&p)
+	if p == nil || p.Name == "" {
+		t.Errorf("expected the pointer passed to GreenRun to be filled")
+	}
+}
+
+func TestGreenRun_TimeRange(t *testing.T) {
+	min := time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC)
+	max := time.Date(1950, 1, 1, 0, 0, 1, 500, time.UTC)
+	f := New().TimeRange(min, max)
+	for i := 0; i < 100; i++ {
+		var tm time.Time
+		f.GreenRun(&tm)
+		if tm.Before(min) || tm.After(max) {
+			t.Errorf("%v is not within [%v, %v]", tm, min, max)
+		}
+	}
+
+	f.TimeRange(max, max)
+	var tm time.Time
+	f.GreenRun(&tm)
+	if !tm.Equal(max) {
+		t.Errorf("expected %v, got %v", max, tm)
+	}
+}
+
+func TestGreenRun_AllowUnexported(t *testing.T) {
+	type inner struct {
+		s string
+	}
+	obj := &struct {
+		a int
+		b *inner
+		c []string
+		D string
+	}{}
+
+	New().GreenRun(obj)
+	if obj.a != 0 || obj.b != nil || obj.c != nil {
+		t.Errorf("expected unexported fields to be left alone: %#v", obj)
+	}
+
+	f := New().NilChance(0).AllowUnexported(true)
+	tryGreenRun(t, f, obj, func() (int, bool) {
+		if obj.a == 0 {
+			return 1, false
+		}
+		if obj.b == nil || obj.b.s == "" {
+			return 2, false
+		}
+		if len(obj.c) == 0 {
+			return 3, false
+		}
+		return 4, obj.D != ""
+	})
+}
+
+func TestGreenRun_mapElements(t *testing.T) {
+	f := New().NilChance(0).NumElements(200, 200)
+	for i := 0; i < 10; i++ {
+		var m map[uint16]string
+		f.GreenRun(&m)
+		if len(m) != 200 {
+			t.Errorf("expected 200 elements, got %v", len(m))
+		}
+	}
+
+	// bool can only produce two distinct keys.
+	var m map[bool]int
+	f.GreenRun(&m)
+	if len(m) > 2 || len(m) == 0 {
+		t.Errorf("expected at most 2 elements, got %v", len(m))
+	}
+}
+
+func TestGreenRunContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	f := New().NilChance(0).NumElements(10, 10).Funcs(func(s *string, c Continue) {
+		calls++
+		if calls == 5 {
+			cancel()
+		}
+	})
+
+	var obj [][]string
+	if err := f.GreenRunContext(ctx, &obj); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if calls != 5 {
+		t.Errorf("expected greenruning to stop after 5 calls, got %v", calls)
+	}
+
+	if err := f.GreenRunContext(context.Background(), &obj); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGreenRun_SkipFields(t *testing.T) {
+	type Meta struct {
+		ResourceVersion string
+		Name            string
+	}
+	obj := &struct {
+		ResourceVersion string
+		Meta            Meta
+		Metas           []Meta
+		resourceVersion string
+	}{}
+
+	f := New().NilChance(0).StringLen(1, 10).SkipFields("ResourceVersion")
+	tryGreenRun(t, f, obj, func() (int, bool) {
+		if obj.ResourceVersion != "" || obj.Meta.ResourceVersion != "" {
+			return 1, false
+		}
+		for _, m := range obj.Metas {
+			if m.ResourceVersion != "" || m.Name == "" {
+				return 2, false
+			}
+		}
+		return 3, obj.Meta.Name != ""
+	})
+
+	// Matching is case-sensitive.
+	f = New().AllowUnexported(true).StringLen(1, 10).SkipFields("resourceversion")
+	f.GreenRun(obj)
+	if obj.ResourceVersion == "" || obj.resourceVersion == "" {
+		t.Errorf("expected fields with differently cased names to be set")
+	}
+}
+
+func TestGreenRun_PreserveNonZero(t *testing.T) {
+	type Inner struct {
+		A, B string
+	}
+	type Outer struct {
+		N     int
+		S     string
+		P     *Inner
+		M     map[string]int
+		In    Inner
+		Empty []int
+	}
+
+	f := New().NilChance(0).StringLen(1, 10).PreserveNonZero(true)
+	for i := 0; i < 20; i++ {
+		p := &Inner{A: "a"}
+		obj := Outer{
+			N:  42,
+			P:  p,
+			M:  map[string]int{"k": 1},
+			In: Inner{A: "in"},
+		}
+		f.GreenRun(&obj)
+		if obj.N != 42 {
+			t.Errorf("expected N to be kept, got %v", obj.N)
+		}
+		if obj.S == "" {
+			t.Errorf("expected S to be filled")
+		}
+		if obj.P != p || obj.P.B != "" {
+			t.Errorf("expected P to be left alone, got %#v", obj.P)
+		}
+		if e, a := map[string]int{"k": 1}, obj.M; !reflect.DeepEqual(e, a) {
+			t.Errorf("expected %v, got %v", e, a)
+		}
+		if obj.In.A != "in" || obj.In.B == "" {
+			t.Errorf("expected In.A to be kept and In.B filled, got %#v", obj.In)
+		}
+		if len(obj.Empty) == 0 {
+			t.Errorf("expected Empty to be filled")
+		}
+	}
+}
+
+func TestGreenRun_EdgeCaseChance(t *testing.T) {
+	obj := &struct {
+		I   int64
+		I8  int8
+		U16 uint16
+		F32 float32
+		F64 float64
+		B   []byte
+	}{}
+
+	f := New().EdgeCaseChance(1)
+	seen := map[interface{}]bool{}
+	for i := 0; i < 200; i++ {
+		f.GreenRun(obj)
+		switch obj.I {
+		case math.MinInt64, math.MaxInt64, -1, 0, 1:
+		default:
+			t.Errorf("unexpected int64 %v", obj.I)
+		}
+		switch obj.I8 {
+		case math.MinInt8, math.MaxInt8, -1, 0, 1:
+		default:
+			t.Errorf("unexpected int8 %v", obj.I8)
+		}
+		switch obj.U16 {
+		case 0, 1, math.MaxUint16:
+		default:
+			t.Errorf("unexpected uint16 %v", obj.U16)
+		}
+		for _, b := range obj.B {
+			if b != 0 && b != 1 && b != math.MaxUint8 {
+				t.Errorf("unexpected byte %v", b)
+			}
+		}
+		if f := obj.F64; f > 0 && f < 1 && f != math.SmallestNonzeroFloat64 {
+			t.Errorf("unexpected float64 %v", f)
+		}
+		if f := obj.F32; f > 0 && f < 1 && f != math.SmallestNonzeroFloat32 {
+			t.Errorf("unexpected float32 %v", f)
+		}
+		seen[obj.I] = true
+		if math.IsNaN(obj.F64) {
+			seen["NaN"] = true
+		}
+		if math.IsInf(float64(obj.F32), 0) {
+			seen["Inf"] = true
+		}
+	}
+	for _, k := range []interface{}{int64(math.MinInt64), int64(math.MaxInt64), int64(0), "NaN", "Inf"} {
+		if !seen[k] {
+			t.Errorf("expected to see %v", k)
+		}
+	}
+
+	// IntRange bounds are the edges when set.
+	f.IntRange(5, 10)
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		if obj.I != 5 && obj.I != 10 {
+			t.Errorf("expected 5 or 10, got %v", obj.I)
+		}
+	}
+}
+
+func TestContinue_RandElementAndRange(t *testing.T) {
+	f := NewWithSeed(1)
+	c := Continue{fc: &greenrunerContext{greenruner: f}, Rand: f.r}
+	seen := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		n := c.RandElement(3)
+		if n < 0 || n >= 3 {
+			t.Errorf("unexpected element %v", n)
+		}
+		seen[n] = true
+		if x := c.RandFloat64Range(-2, 2); x < -2 || x >= 2 {
+			t.Errorf("unexpected float %v", x)
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected to see all 3 elements, saw %v", seen)
+	}
+
+	// The same seed gives the same values.
+	f2 := NewWithSeed(1)
+	c1 := Continue{fc: &greenrunerContext{greenruner: f}, Rand: rand.New(rand.NewSource(7))}
+	c2 := Continue{fc: &greenrunerContext{greenruner: f2}, Rand: rand.New(rand.NewSource(7))}
+	if c1.RandElement(1000) != c2.RandElement(1000) || c1.RandFloat64Range(0, 1) != c2.RandFloat64Range(0, 1) {
+		t.Errorf("expected repeatable values")
+	}
+}
+
+func TestGreenRun_Clone(t *testing.T) {
+	f := NewWithSeed(1).NilChance(0).NumElements(2, 2).Funcs(
+		func(s *string, c Continue) { *s = "original" },
+	)
+	c := f.Clone()
+	c.Funcs(func(s *string, c Continue) { *s = "clone" }).SkipFields("B")
+
+	var obj struct {
+		A []string
+		B string
+	}
+	f.GreenRun(&obj)
+	if e, a := []string{"original", "original"}, obj.A; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if obj.B != "original" {
+		t.Errorf("expected B to be set by the original's func, got %q", obj.B)
+	}
+
+	obj.B = ""
+	c.GreenRun(&obj)
+	if e, a := []string{"clone", "clone"}, obj.A; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if obj.B != "" {
+		t.Errorf("expected B to be skipped, got %q", obj.B)
+	}
+
+	// Clones of identically seeded GreenRunners agree.
+	var i1, i2 int
+	NewWithSeed(5).Clone().GreenRun(&i1)
+	NewWithSeed(5).Clone().GreenRun(&i2)
+	if i1 != i2 {
+		t.Errorf("expected equal values, got %v and %v", i1, i2)
+	}
+}
+
+func TestGreenRun_NilChanceFor(t *testing.T) {
+	obj := &struct {
+		P *int
+		S []int
+		M map[int]int
+	}{}
+
+	f := New().NilChance(.5).NilChanceFor(reflect.Ptr, 0).NilChanceFor(reflect.Slice, 1)
+	sawNilMap, sawMap := false, false
+	for i := 0; i < 100; i++ {
+		f.GreenRun(obj)
+		if obj.P == nil {
+			t.Errorf("expected P to never be nil")
+		}
+		if obj.S != nil {
+			t.Errorf("expected S to always be nil")
+		}
+		if obj.M == nil {
+			sawNilMap = true
+		} else {
+			sawMap = true
+		}
+	}
+	if !sawNilMap || !sawMap {
+		t.Errorf("expected maps to fall back to NilChance")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for an invalid chance")
+		}
+	}()
+	f.NilChanceFor(reflect.Map, 1.5)
+}
+
+func TestGreenRun_EmptyChance(t *testing.T) {
+	obj := &struct {
+		S []string
+		M map[string]int
+	}{}
+
+	f := New().NilChance(0).NumElements(1, 3).EmptyChance(1)
+	for i := 0; i < 20; i++ {
+		f.GreenRun(obj)
+		if obj.S == nil || len(obj.S) != 0 {
+			t.Errorf("expected an empty, non-nil slice, got %#v", obj.S)
+		}
+		if obj.M == nil || len(obj.M) != 0 {
+			t.Errorf("expected an empty, non-nil map, got %#v", obj.M)
+		}
+	}
+
+	f.NilChance(.5).EmptyChance(.5)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		f.GreenRun(obj)
+		switch {
+		case obj.S == nil:
+			seen["nil"] = true
+		case len(obj.S) == 0:
+			seen["empty"] = true
+		default:
+			seen["filled"] = true
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected nil, empty and filled slices, saw %v", seen)
+	}
+}
+
+func TestGreenRunValue(t *testing.T) {
+	obj := struct {
+		A string
+		B []int
+	}{}
+
+	f := New().NilChance(0).StringLen(1, 10)
+	f.GreenRunValue(reflect.ValueOf(&obj).Elem().Field(1))
+	if obj.A != "" || len(obj.B) == 0 {
+		t.Errorf("expected only B to be filled, got %#v", obj)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for an unsettable value")
+		}
+	}()
+	f.GreenRunValue(reflect.ValueOf(obj))
+}
+
+func TestGreenRun_customSlice(t *testing.T) {
+	obj := &struct {
+		A []string
+		B *[]string
+		C []int
+	}{}
+
+	f := New().NilChance(0).NumElements(3, 3).Funcs(
+		func(s []string, c Continue) {
+			for i := range s {
+				s[i] = "elem"
+			}
+		},
+		// Pointers to slices still take precedence.
+		func(s *[]int, c Continue) {
+			*s = []int{1}
+		},
+	)
+	f.GreenRun(obj)
+	if e, a := []string{"elem", "elem", "elem"}, obj.A; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if obj.B == nil || !reflect.DeepEqual([]string{"elem", "elem", "elem"}, *obj.B) {
+		t.Errorf("expected B to be filled by the custom func, got %v", obj.B)
+	}
+	if e, a := []int{1}, obj.C; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+}
+
+func TestGreenRun_customError(t *testing.T) {
+	type Window struct {
+		Start, End int
+	}
+	errInvalid := errors.New("invalid window")
+
+	f := New().Funcs(
+		func(w *Window, c Continue) error {
+			c.GreenRunNoCustom(w)
+			if w.Start > w.End {
+				return errInvalid
+			}
+			return nil
+		},
+	)
+	var obj struct {
+		W Window
+	}
+	sawErr, sawOK := false, false
+	for i := 0; i < 50; i++ {
+		err := f.GreenRunE(&obj)
+		switch {
+		case err == nil:
+			sawOK = true
+		case errors.Is(err, errInvalid):
+			sawErr = true
+			if !strings.HasSuffix(err.Error(), "at .W") {
+				t.Errorf("expected the error to name the field, got %v", err)
+			}
+		default:
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	if !sawErr || !sawOK {
+		t.Errorf("expected both errors and successes")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a func with a non-error result")
+		}
+	}()
+	New().Funcs(func(w *Window, c Continue) int { return 0 })
+}
+
+type depthTree struct {
+	Children []*depthTree
+}
+
+func TestContinue_Depth(t *testing.T) {
+	var depths []int
+	f := New().NilChance(0).NumElements(1, 1).Funcs(
+		func(tree *depthTree, c Continue) {
+			depths = append(depths, c.Depth())
+			// Stop nesting past a fixed depth.
+			if c.Depth() < 6 {
+				c.GreenRunNoCustom(tree)
+			}
+		},
+	)
+
+	var tree depthTree
+	f.GreenRun(&tree)
+	if e, a := []int{1, 4, 7}, depths; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected depths %v, got %v", e, a)
+	}
+	if len(tree.Children) != 1 || len(tree.Children[0].Children) != 1 || tree.Children[0].Children[0].Children != nil {
+		t.Errorf("expected a tree of depth 3")
+	}
+}
+
+func TestGreenRun_KindFuncs(t *testing.T) {
+	obj := &struct {
+		I8  int8
+		I32 int32
+		I   int
+		B   []byte
+		S   string
+	}{}
+
+	positive := func(v reflect.Value, r *rand.Rand) {
+		v.SetInt(1 + r.Int63n(100))
+	}
+	f := New().NilChance(0).StringLen(1, 10).
+		KindFuncs(reflect.Int8, positive).
+		KindFuncs(reflect.Int32, positive).
+		KindFuncs(reflect.Int, positive).
+		KindFuncs(reflect.Uint8, func(v reflect.Value, r *rand.Rand) { v.SetUint(9) })
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		if obj.I8 < 1 || obj.I32 < 1 || obj.I < 1 || obj.I > 100 {
+			t.Errorf("expected positive ints, got %#v", obj)
+		}
+		for _, b := range obj.B {
+			if b != 9 {
+				t.Errorf("expected bytes to be set by the kind func, got %v", obj.B)
+			}
+		}
+		if obj.S == "" {
+			t.Errorf("expected strings to keep their default behavior")
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a non-primitive kind")
+		}
+	}()
+	f.KindFuncs(reflect.Struct, positive)
+}
+
+func TestGreenRun_OnUnhandled(t *testing.T) {
+	obj := &struct {
+		C complex128
+		F func()
+		I fmt.Stringer
+	}{}
+
+	var seen []string
+	f := New().NilChance(0).OnUnhandled(func(v reflect.Value, c Continue) bool {
+		seen = append(seen, v.Type().String())
+		if v.Kind() == reflect.Complex128 {
+			v.SetComplex(complex(c.Float64(), c.Float64()))
+			return true
+		}
+		return v.Kind() == reflect.Func
+	})
+	f.GreenRun(&obj.C)
+	f.GreenRun(&obj.F)
+	if obj.C == 0 {
+		t.Errorf("expected the complex value to be filled")
+	}
+	if want := []string{"complex128", "func()"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("expected %v to be unhandled, got %v", want, seen)
+	}
+
+	if err := f.GreenRunE(&obj.I); err == nil || !strings.Contains(err.Error(), "can't handle fmt.Stringer") {
+		t.Errorf("expected an error when OnUnhandled declines, got %v", err)
+	}
+}
+
+func TestGreenRunner_Seed(t *testing.T) {
+	f := New()
+	var a, b [10]int
+	f.GreenRun(&a)
+	NewWithSeed(f.Seed()).GreenRun(&b)
+	if a != b {
+		t.Errorf("expected NewWithSeed(f.Seed()) to generate %v, got %v", a, b)
+	}
+
+	c := f.Clone()
+	c.GreenRun(&a)
+	NewWithSeed(c.Seed()).GreenRun(&b)
+	if a != b {
+		t.Errorf("expected a clone's seed to reproduce its values, got %v and %v", a, b)
+	}
+}
+
+func TestGreenRunUnique(t *testing.T) {
+	var objs []uint8
+	New().GreenRunUnique(&objs, 64)
+	if len(objs) != 64 {
+		t.Fatalf("expected 64 elements, got %d", len(objs))
+	}
+	seen := map[uint8]bool{}
+	for _, o := range objs {
+		if seen[o] {
+			t.Errorf("expected distinct elements, got %v twice", o)
+		}
+		seen[o] = true
+	}
+
+	// Only two values exist, so the rest must repeat.
+	var bools []bool
+	New().GreenRunUnique(&bools, 10)
+	if len(bools) != 10 {
+		t.Errorf("expected 10 elements, got %d", len(bools))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a non-slice argument")
+		}
+	}()
+	New().GreenRunUnique(&seen, 1)
+}
+
+func TestGreenRun_FloatMode(t *testing.T) {
+	var obj struct {
+		F32 float32
+		F64 float64
+	}
+
+	f := New()
+	for i := 0; i < 100; i++ {
+		f.GreenRun(&obj)
+		if obj.F32 < 0 || obj.F32 >= 1 || obj.F64 < 0 || obj.F64 >= 1 {
+			t.Fatalf("expected floats in [0, 1) by default, got %v", obj)
+		}
+	}
+
+	f = New().FloatMode(FloatFinite)
+	sawNegative, sawLarge := false, false
+	for i := 0; i < 100; i++ {
+		f.GreenRun(&obj)
+		for _, x := range []float64{float64(obj.F32), obj.F64} {
+			if math.IsInf(x, 0) || math.IsNaN(x) {
+				t.Fatalf("expected finite floats, got %v", x)
+			}
+			sawNegative = sawNegative || x < 0
+			sawLarge = sawLarge || math.Abs(x) > 1
+		}
+	}
+	if !sawNegative || !sawLarge {
+		t.Errorf("expected negative and large floats, got negative=%v large=%v", sawNegative, sawLarge)
+	}
+
+	// Feed all-ones bits, which are NaN for both sizes.
+	ones := bytes.Repeat([]byte{0xff}, 64)
+	NewFromBytes(ones).FloatMode(FloatFull).GreenRun(&obj)
+	if !math.IsNaN(float64(obj.F32)) || !math.IsNaN(obj.F64) {
+		t.Errorf("expected NaNs from all-ones bits, got %v", obj)
+	}
+}
+
+func TestGreenRun_FloatRange(t *testing.T) {
+	var obj struct {
+		F32 float32
+		F64 float64
+	}
+
+	f := New().FloatRange(-1000, 1000)
+	sawNegative := false
+	for i := 0; i < 100; i++ {
+		f.GreenRun(&obj)
+		for _, x := range []float64{float64(obj.F32), obj.F64} {
+			if x < -1000 || x > 1000 {
+				t.Fatalf("expected floats in [-1000, 1000], got %v", x)
+			}
+			sawNegative = sawNegative || x < 0
+		}
+	}
+	if !sawNegative {
+		t.Errorf("expected some negative floats")
+	}
+
+	f = New().FloatRange(-math.MaxFloat64, math.MaxFloat64)
+	for i := 0; i < 100; i++ {
+		f.GreenRun(&obj.F64)
+		if math.IsInf(obj.F64, 0) || math.IsNaN(obj.F64) {
+			t.Fatalf("expected finite floats for the widest range, got %v", obj.F64)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for min > max")
+		}
+	}()
+	New().FloatRange(1, 0)
+}
+
+func TestGreenRun_Duration(t *testing.T) {
+	var obj struct {
+		D  time.Duration
+		DP *time.Duration
+	}
+
+	f := New().NilChance(0)
+	for i := 0; i < 100; i++ {
+		f.GreenRun(&obj)
+		for _, d := range []time.Duration{obj.D, *obj.DP} {
+			if d < 0 || d > 24*time.Hour {
+				t.Fatalf("expected durations within a day by default, got %v", d)
+			}
+		}
+	}
+
+	f.DurationRange(time.Second, time.Minute)
+	for i := 0; i < 100; i++ {
+		f.GreenRun(&obj)
+		if obj.D < time.Second || obj.D > time.Minute {
+			t.Fatalf("expected durations in [1s, 1m], got %v", obj.D)
+		}
+	}
+}
+
+func TestGreenRunParallel(t *testing.T) {
+	gen := func(workers int) []int64 {
+		vals := make([]int64, 100)
+		objs := make([]interface{}, len(vals))
+		for i := range vals {
+			objs[i] = &vals[i]
+		}
+		NewWithSeed(42).GreenRunParallel(objs, workers)
+		return vals
+	}
+
+	a, b := gen(4), gen(4)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("expected the same seed and worker count to generate the same values")
+	}
+	for i, v := range a {
+		if v == 0 {
+			t.Errorf("expected element %d to be filled", i)
+		}
+	}
+
+	// A panic in a goroutine reaches the caller rather than crashing.
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected the custom function's panic, got %v", r)
+			}
+		}()
+		var a, b int8
+		New().Funcs(func(*int8, Continue) {
+			panic("boom")
+		}).GreenRunParallel([]interface{}{&a, &b}, 2)
+	}()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a non-pointer")
+		}
+	}()
+	New().GreenRunParallel([]interface{}{1}, 2)
+}
+
+func TestGreenRun_MaxTotalElements(t *testing.T) {
+	var obj struct {
+		S [][]int
+		M map[int][]string
+	}
+
+	count := func() int {
+		n := len(obj.S) + len(obj.M)
+		for _, s := range obj.S {
+			n += len(s)
+		}
+		for _, s := range obj.M {
+			n += len(s)
+		}
+		return n
+	}
+
+	f := New().NilChance(0).NumElements(5, 10).MaxTotalElements(20)
+	for i := 0; i < 20; i++ {
+		f.GreenRun(&obj)
+		if n := count(); n > 20 {
+			t.Fatalf("expected at most 20 elements, got %d", n)
+		}
+		if len(obj.S) == 0 {
+			t.Fatalf("expected the first collection to get elements")
+		}
+		if obj.M == nil {
+			t.Fatalf("expected maps past the budget to be empty, not nil")
+		}
+	}
+}
+
+// TestGreenRun_stableOutput guards the documented promise that a seed and
+// configuration always generate the same values. If it fails, a change has
+// altered the sequence of random draws and should be put behind an option.
+func TestGreenRun_stableOutput(t *testing.T) {
+	type golden struct {
+		I  int
+		U8 uint8
+		F  float64
+		B  bool
+		S  string
+		L  []int16
+		M  map[string]uint32
+		A  [3]byte
+	}
+
+	var obj golden
+	NewWithSeed(1).NilChance(0).NumElements(2, 2).StringLen(1, 5).GreenRun(&obj)
+	want := golden{
+		I:  -7292730486042577890,
+		U8: 0x76,
+		F:  0.4246374970712657,
+		B:  false,
+		S:  "蟲",
+		L:  []int16{-19105, -16413},
+		M:  map[string]uint32{"7崛瀇莒A": 0x34e299f0, "p": 0x288833b6},
+		A:  [3]byte{0x99, 0xef, 0x25},
+	}
+	if !reflect.DeepEqual(obj, want) {
+		t.Errorf("expected %#v, got %#v", want, obj)
+	}
+}
+
+func TestGreenRun_PrintableStrings(t *testing.T) {
+	f := New().StringLen(20, 20).PrintableStrings(true)
+	c := Continue{fc: &greenrunerContext{greenruner: f}, Rand: f.r}
+	for i := 0; i < 100; i++ {
+		var s string
+		f.GreenRun(&s)
+		for _, str := range []string{s, c.RandString()} {
+			for _, r := range str {
+				if !unicode.IsPrint(r) {
+					t.Fatalf("expected printable strings, got %q in %q", r, str)
+				}
+			}
+		}
+	}
+
+	// No printable characters at all leaves nothing to pick.
+	var s string
+	New().StringLen(5, 5).Charset([2]rune{0, 0x1f}).PrintableStrings(true).GreenRun(&s)
+	if s != "" {
+		t.Errorf("expected an empty string, got %q", s)
+	}
+}
+
+func TestGreenRun_Wordlist(t *testing.T) {
+	words := []string{"alpha", "beta", "gamma"}
+	f := New().StringLen(1, 4).Wordlist(words)
+	c := Continue{fc: &greenrunerContext{greenruner: f}, Rand: f.r}
+	for i := 0; i < 50; i++ {
+		var s string
+		f.GreenRun(&s)
+		parts := strings.Split(s, " ")
+		if len(parts) < 1 || len(parts) > 4 {
+			t.Errorf("expected 1 to 4 words, got %q", s)
+		}
+		for _, w := range append(parts, strings.Split(c.RandWords(2), " ")...) {
+			if w != "alpha" && w != "beta" && w != "gamma" {
+				t.Errorf("unexpected word %q", w)
+			}
+		}
+	}
+}
+
+func TestGreenRun_arraysAlwaysFilled(t *testing.T) {
+	obj := &struct {
+		ID    [16]byte
+		Pairs [2][2]int
+		Names [3]string
+	}{}
+
+	f := New().NilChance(.5).StringLen(1, 10)
+	for i := 0; i < 100; i++ {
+		f.GreenRun(obj)
+		if obj.ID == [16]byte{} {
+			t.Fatalf("expected ID to be filled")
+		}
+		for _, p := range obj.Pairs {
+			if p == [2]int{} {
+				t.Fatalf("expected Pairs to be filled, got %v", obj.Pairs)
+			}
+		}
+		for _, n := range obj.Names {
+			if n == "" {
+				t.Fatalf("expected Names to be filled, got %q", obj.Names)
+			}
+		}
+	}
+
+	f.NilChanceFor(reflect.Array, 1)
+	f.GreenRun(obj)
+	if obj.ID != [16]byte{} || obj.Pairs != [2][2]int{} || obj.Names != [3]string{} {
+		t.Errorf("expected arrays to be left zero, got %+v", obj)
+	}
+}
+
+func TestGreenRun_NonZero(t *testing.T) {
+	obj := &struct {
+		I8  int8
+		U8  uint8
+		F32 float32
+		S   string
+		B   []byte
+	}{}
+
+	f := New().NilChance(0).IntRange(0, 1).StringLen(0, 1).NonZero(true)
+	for i := 0; i < 100; i++ {
+		f.GreenRun(obj)
+		if obj.I8 == 0 || obj.U8 == 0 || obj.F32 == 0 || obj.S == "" {
+			t.Fatalf("expected no zero values, got %+v", obj)
+		}
+		for _, b := range obj.B {
+			if b == 0 {
+				t.Fatalf("expected no zero bytes, got %v", obj.B)
+			}
+		}
+	}
+
+	// Nothing but zero is possible, so it is kept rather than looping.
+	var n int
+	New().IntRange(0, 0).NonZero(true).GreenRun(&n)
+	if n != 0 {
+		t.Errorf("expected 0, got %v", n)
+	}
+}
+
+func TestGreenRun_stableMapsJSON(t *testing.T) {
+	type obj struct {
+		M  map[string]int
+		MM map[int]map[string][]string
+	}
+
+	gen := func() string {
+		var o obj
+		NewWithSeed(99).NilChance(0).NumElements(5, 10).GreenRun(&o)
+		b, err := json.Marshal(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	want := gen()
+	for i := 0; i < 10; i++ {
+		if got := gen(); got != want {
+			t.Fatalf("expected the same JSON for the same seed, got %s and %s", want, got)
+		}
+	}
+}
+
+type EmbeddedBase struct {
+	ID   int
+	Note string
+}
+
+func TestGreenRun_embeddedCustom(t *testing.T) {
+	obj := &struct {
+		EmbeddedBase
+		*EmbeddedPtr
+		Name string
+	}{}
+
+	f := New().NilChance(0).StringLen(1, 10).Funcs(
+		func(b *EmbeddedBase, c Continue) {
+			b.ID, b.Note = -1, "custom"
+		},
+		func(p *EmbeddedPtr, c Continue) {
+			p.N = 42
+		},
+	)
+	f.GreenRun(obj)
+	if obj.ID != -1 || obj.Note != "custom" {
+		t.Errorf("expected the embedded struct's custom func to fill it, got %+v", obj.EmbeddedBase)
+	}
+	if obj.EmbeddedPtr == nil || obj.N != 42 {
+		t.Errorf("expected the embedded pointer's custom func to fill it, got %+v", obj.EmbeddedPtr)
+	}
+	if obj.Name == "" {
+		t.Errorf("expected the outer fields to be filled")
+	}
+}
+
+type EmbeddedPtr struct {
+	N int
+}
+
+func TestGreenRun_pointerToInterface(t *testing.T) {
+	obj := &struct {
+		S *fmt.Stringer
+	}{}
+
+	f := New().NilChance(0).InterfaceImpls(reflect.TypeOf((*fmt.Stringer)(nil)).Elem(), time.Duration(0), &net.IPAddr{})
+	sawDuration, sawAddr := false, false
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		if obj.S == nil || *obj.S == nil {
+			t.Fatalf("expected the pointer and interface to be filled")
+		}
+		switch (*obj.S).(type) {
+		case time.Duration:
+			sawDuration = true
+		case *net.IPAddr:
+			sawAddr = true
+		}
+	}
+	if !sawDuration || !sawAddr {
+		t.Errorf("expected both implementations, got Duration=%v IPAddr=%v", sawDuration, sawAddr)
+	}
+}
+
+func TestGreenRunner_DisableDefault(t *testing.T) {
+	obj := &struct {
+		T time.Time
+		D time.Duration
+	}{}
+
+	f := New().NilChance(0).DisableDefault(reflect.TypeOf(time.Time{}))
+	c := f.Clone().DisableDefault(reflect.TypeOf(time.Duration(0)))
+	sawLongDuration := false
+	for i := 0; i < 20; i++ {
+		f.GreenRun(obj)
+		if !obj.T.IsZero() {
+			t.Fatalf("expected time.Time to be left zero, got %v", obj.T)
+		}
+		if obj.D < 0 || obj.D > 24*time.Hour {
+			t.Fatalf("expected the clone's change not to affect f, got %v", obj.D)
+		}
+		c.GreenRun(obj)
+		sawLongDuration = sawLongDuration || obj.D < 0 || obj.D > 24*time.Hour
+	}
+	if !sawLongDuration {
+		t.Errorf("expected durations to be greenruned as plain int64s")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a pointer type")
+		}
+	}()
+	New().DisableDefault(reflect.TypeOf(&time.Time{}))
+}
+
+func TestGreenRunner_NumElementsFor(t *testing.T) {
+	obj := &struct {
+		Events []int
+		Counts map[string]int
+		Other  []string
+	}{}
+
+	f := New().NilChance(0).NumElements(4, 4).
+		NumElementsFor(reflect.TypeOf([]int(nil)), 100, 200).
+		NumElementsFor(reflect.TypeOf(map[string]int(nil)), 1, 3)
+	for i := 0; i < 20; i++ {
+		f.GreenRun(obj)
+		if n := len(obj.Events); n < 100 || n > 200 {
+			t.Errorf("expected 100 to 200 events, got %d", n)
+		}
+		if n := len(obj.Counts); n < 1 || n > 3 {
+			t.Errorf("expected 1 to 3 counts, got %d", n)
+		}
+		if n := len(obj.Other); n != 4 {
+			t.Errorf("expected other slices to follow NumElements, got %d", n)
+		}
+	}
+}
+
+func TestGreenRun_mapStructValues(t *testing.T) {
+	type point struct {
+		X, Y int
+		Tag  string
+	}
+	obj := &struct {
+		M map[string]point
+	}{}
+
+	f := New().NilChance(0).NumElements(3, 3).StringLen(1, 10)
+	for i := 0; i < 20; i++ {
+		f.GreenRun(obj)
+		if len(obj.M) == 0 {
+			t.Fatalf("expected map entries")
+		}
+		for k, p := range obj.M {
+			if p.X == 0 && p.Y == 0 || p.Tag == "" {
+				t.Errorf("expected the value for %q to be filled, got %+v", k, p)
+			}
+		}
+	}
+}
+
+func TestGreenRunner_Validate(t *testing.T) {
+	type span struct {
+		Start, End int
+	}
+
+	f := New().Validate(func(obj interface{}) bool {
+		s := obj.(*span)
+		return s.Start < s.End
+	})
+	for i := 0; i < 50; i++ {
+		var s span
+		f.GreenRun(&s)
+		if s.Start >= s.End {
+			t.Fatalf("expected Start before End, got %+v", s)
+		}
+	}
+
+	f.Validate(func(interface{}) bool { return false })
+	var s span
+	if err := f.GreenRunE(&s); err == nil || !strings.Contains(err.Error(), "100 attempts") {
+		t.Errorf("expected an error once attempts run out, got %v", err)
+	}
+
+	var nilSpan *span
+	if err := f.GreenRunE(nilSpan); err == nil {
+		t.Errorf("expected an error for a nil pointer")
+	}
+}
+
+func TestContinue_Index(t *testing.T) {
+	type item struct {
+		Pos int
+	}
+	obj := &struct {
+		Items []item
+		Arr   [3]item
+		Ptrs  []*item
+		One   item
+		M     map[string]item
+	}{}
+
+	f := New().NilChance(0).NumElements(4, 4).Funcs(func(it *item, c Continue) {
+		it.Pos = c.Index()
+	})
+	f.GreenRun(obj)
+	for i, it := range obj.Items {
+		if it.Pos != i {
+			t.Errorf("expected Items[%d] to have index %d, got %d", i, i, it.Pos)
+		}
+	}
+	for i, it := range obj.Arr {
+		if it.Pos != i {
+			t.Errorf("expected Arr[%d] to have index %d, got %d", i, i, it.Pos)
+		}
+	}
+	for i, it := range obj.Ptrs {
+		if it.Pos != i {
+			t.Errorf("expected Ptrs[%d] to have index %d, got %d", i, i, it.Pos)
+		}
+	}
+	if obj.One.Pos != -1 {
+		t.Errorf("expected a plain field to have index -1, got %d", obj.One.Pos)
+	}
+	for k, it := range obj.M {
+		if it.Pos != -1 {
+			t.Errorf("expected map value %q to have index -1, got %d", k, it.Pos)
+		}
+	}
+}
+
+func TestGreenRunner_AnyTypes(t *testing.T) {
+	obj := &struct {
+		A interface{}
+		S fmt.Stringer
+	}{}
+
+	if err := New().GreenRunE(&obj.A); err == nil {
+		t.Errorf("expected an error without any candidate types")
+	}
+
+	f := New().NilChance(0).AnyTypes(reflect.TypeOf(0), reflect.TypeOf(&net.IPAddr{}))
+	sawInt, sawAddr := false, false
+	for i := 0; i < 50; i++ {
+		f.GreenRun(&obj.A)
+		switch obj.A.(type) {
+		case int:
+			sawInt = true
+		case *net.IPAddr:
+			sawAddr = true
+		default:
+			t.Fatalf("unexpected type %T", obj.A)
+		}
+	}
+	if !sawInt || !sawAddr {
+		t.Errorf("expected both candidate types, got int=%v *net.IPAddr=%v", sawInt, sawAddr)
+	}
+
+	if err := f.GreenRunE(&obj.S); err == nil {
+		t.Errorf("expected AnyTypes not to apply to interfaces with methods")
+	}
+
+	withFunc := &struct {
+		A  interface{}
+		Fn func()
+	}{}
+	err := f.GreenRunE(withFunc)
+	if err == nil || !strings.Contains(err.Error(), "Fn") {
+		t.Errorf("expected an error naming the func field, got %v", err)
+	}
+	if err := f.GreenRunFuncs(true).GreenRunE(withFunc); err != nil || withFunc.A == nil || withFunc.Fn == nil {
+		t.Errorf("expected both fields to be filled with GreenRunFuncs, got %+v, %v", withFunc, err)
+	}
+}
+
+func TestGreenRunner_ReproOnPanic(t *testing.T) {
+	var buf strings.Builder
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	var seen int64
+	record := func(n *int64, c Continue) {
+		*n = c.Int63()
+		seen = *n
+	}
+	f := New().ReproOnPanic(true).Funcs(func(n *int64, c Continue) {
+		record(n, c)
+		panic("boom")
+	})
+	var n int64
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected the panic to continue, got %v", r)
+			}
+		}()
+		f.GreenRun(&n)
+	}()
+
+	var seed int64
+	i := strings.Index(buf.String(), "NewWithSeed(")
+	if i < 0 {
+		t.Fatalf("expected a reproduction hint, got %q", buf.String())
+	}
+	if _, err := fmt.Sscanf(buf.String()[i:], "NewWithSeed(%d)", &seed); err != nil {
+		t.Fatalf("couldn't parse the seed from %q: %v", buf.String(), err)
+	}
+	want := seen
+	NewWithSeed(seed).Funcs(record).GreenRun(&n)
+	if n != want {
+		t.Errorf("expected the seed to reproduce %v, got %v", want, n)
+	}
+}
+
+func TestGreenRunner_TraceTo(t *testing.T) {
+	type node struct {
+		P    *int
+		L    []string
+		Skip int `greenrun:"-"`
+	}
+
+	var buf strings.Builder
+	f := New().NilChance(0).NumElements(2, 2).TraceTo(&buf)
+	f.GreenRun(&node{})
+	for _, want := range []string{
+		"node.P (ptr): filled pointer\n",
+		"node.L (slice): slice with 2 elements\n",
+		"node.Skip (int): skipped\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected the trace to contain %q, got:\n%v", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	f.NilChance(1).GreenRun(&node{})
+	if !strings.Contains(buf.String(), "node.P (ptr): nil pointer\n") {
+		t.Errorf("expected the trace to show a nil pointer, got:\n%v", buf.String())
+	}
+}
+
+func TestCharRange_choose(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	cr := charRange{'a', 'c'}
+	sawLast := false
+	for i := 0; i < 100; i++ {
+		c := cr.choose(r, true)
+		if c < 'a' || c > 'c' {
+			t.Fatalf("expected a rune in [a, c], got %q", c)
+		}
+		sawLast = sawLast || c == 'c'
+	}
+	if !sawLast {
+		t.Errorf("expected the last rune of the range to be chosen")
+	}
+
+	// Without assigned, the last rune of the range is never chosen, which
+	// keeps the values generated for existing seeds.
+	for i := 0; i < 100; i++ {
+		if c := cr.choose(r, false); c < 'a' || c > 'b' {
+			t.Fatalf("expected a rune in [a, b], got %q", c)
+		}
+	}
+
+	// Surrounds the surrogates, which aren't valid runes, and unassigned
+	// code points around them.
+	cr = charRange{'\ud7a0', '\ue000'}
+	for i := 0; i < 1000; i++ {
+		if c := cr.choose(r, true); !utf8.ValidRune(c) || !assignedRune(c) {
+			t.Fatalf("expected a valid, assigned rune, got %U", c)
+		}
+	}
+	for _, cr := range unicodeRanges {
+		for i := 0; i < 1000; i++ {
+			if c := cr.choose(r, true); !utf8.ValidRune(c) || c == utf8.RuneError {
+				t.Fatalf("expected a valid rune, got %U", c)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a range of surrogates")
+		}
+	}()
+	New().Charset([2]rune{0xd800, 0xdfff})
+}
+
+func TestGreenRunner_ElementDistribution(t *testing.T) {
+	for _, d := range []ElementDistribution{ElementsUniform, ElementsGeometric, ElementsZipf} {
+		f := New().NilChance(0).NumElements(0, 50).ElementDistribution(d)
+		small := 0
+		for i := 0; i < 1000; i++ {
+			var s []bool
+			f.GreenRun(&s)
+			if len(s) > 50 {
+				t.Fatalf("%v: expected at most 50 elements, got %d", d, len(s))
+			}
+			if len(s) < 5 {
+				small++
+			}
+		}
+		// A uniform count is below 5 about a tenth of the time; the skewed
+		// distributions are there most of the time.
+		if skewed := small > 500; skewed != (d != ElementsUniform) {
+			t.Errorf("%v: got %d small collections out of 1000", d, small)
+		}
+	}
+}
+
+func TestGreenRunner_ShuffleFields(t *testing.T) {
+	type fields struct {
+		A, B, C, D int
+	}
+
+	orders := map[string]bool{}
+	var order []string
+	f := New().ShuffleFields(true).Funcs(func(n *int, c Continue) {
+		order = append(order, c.Path())
+	})
+	for i := 0; i < 50; i++ {
+		order = nil
+		f.GreenRun(&fields{})
+		if len(order) != 4 {
+			t.Fatalf("expected every field to be greenruned once, got %v", order)
+		}
+		orders[strings.Join(order, ",")] = true
+	}
+	if len(orders) < 2 {
+		t.Errorf("expected fields in various orders, got %v", orders)
+	}
+}
+
+func TestGreenRunner_MaxStringBytes(t *testing.T) {
+	// CJK characters take 3 bytes each, so 32 bytes can't hold 20 of them.
+	f := New().StringLen(20, 20).Charset([2]rune{'\u4e00', '\u9fff'}).MaxStringBytes(32)
+	for i := 0; i < 50; i++ {
+		var s string
+		f.GreenRun(&s)
+		if len(s) != 30 || !utf8.ValidString(s) {
+			t.Fatalf("expected 10 whole CJK characters, got %q (%d bytes)", s, len(s))
+		}
+	}
+
+	f = New().StringLen(0, 40).MaxStringBytes(10)
+	for i := 0; i < 100; i++ {
+		var s string
+		f.GreenRun(&s)
+		if len(s) > 10 || !utf8.ValidString(s) {
+			t.Fatalf("expected at most 10 bytes of valid UTF-8, got %q", s)
+		}
+	}
+
+	f = New().StringLen(5, 5).Wordlist([]string{"hello"}).MaxStringBytes(8)
+	var s string
+	f.GreenRun(&s)
+	if s != "hello he" {
+		t.Errorf("expected words to be cut at 8 bytes, got %q", s)
+	}
+}
+
+func TestGreenRun_topLevelPointer(t *testing.T) {
+	type foo struct {
+		A int
+		B *int
+	}
+
+	f := New().NilChance(1)
+	for i := 0; i < 20; i++ {
+		var p *foo
+		f.GreenRun(&p)
+		if p == nil {
+			t.Fatalf("expected the pointer passed to GreenRun to be filled")
+		}
+		if p.B != nil {
+			t.Fatalf("expected nested pointers to follow NilChance")
+		}
+	}
+}
+
+func TestGreenRunner_MinNonEmpty(t *testing.T) {
+	obj := &struct {
+		S []int
+		M map[string]int
+		O []string
+	}{}
+
+	f := New().NilChance(.5).EmptyChance(.5).NumElements(0, 3).
+		MinNonEmpty(reflect.TypeOf([]int(nil)), reflect.TypeOf(map[string]int(nil)))
+	sawEmpty := false
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		if len(obj.S) == 0 || len(obj.M) == 0 {
+			t.Fatalf("expected non-empty collections, got %v and %v", obj.S, obj.M)
+		}
+		sawEmpty = sawEmpty || len(obj.O) == 0
+	}
+	if !sawEmpty {
+		t.Errorf("expected other slices to be empty sometimes")
+	}
+}
+
+func TestGreenRun_MaxDepthWideStruct(t *testing.T) {
+	// A struct with 150 fields is wide, not deep, so it must fill entirely
+	// with a MaxDepth well below its number of fields.
+	fields := make([]reflect.StructField, 150)
+	for i := range fields {
+		fields[i] = reflect.StructField{Name: fmt.Sprintf("F%d", i), Type: reflect.TypeOf(int64(0))}
+	}
+	v := reflect.New(reflect.StructOf(fields))
+
+	New().MaxDepth(2).GreenRun(v.Interface())
+	zeros := 0
+	for i := 0; i < v.Elem().NumField(); i++ {
+		if v.Elem().Field(i).Int() == 0 {
+			zeros++
+		}
+	}
+	if zeros > 0 {
+		t.Errorf("expected every field to be filled, %d were left zero", zeros)
+	}
+}
+
+func TestGreenRunner_Fuzz(t *testing.T) {
+	var a, b [4]int
+	NewWithSeed(3).GreenRun(&a)
+	NewWithSeed(3).Fuzz(&b)
+	if a != b {
+		t.Errorf("expected Fuzz to match GreenRun, got %v and %v", a, b)
+	}
+}
+
+func TestGreenRunner_InterfaceImplsWeighted(t *testing.T) {
+	shapeType := reflect.TypeOf((*testShape)(nil)).Elem()
+	f := New().InterfaceImplsWeighted(shapeType, []interface{}{testSquare{}, &testRect{}}, []int{4, 1})
+
+	squares := 0
+	for i := 0; i < 1000; i++ {
+		var s testShape
+		f.GreenRun(&s)
+		if _, ok := s.(testSquare); ok {
+			squares++
+		}
+	}
+	if squares < 700 || squares > 900 {
+		t.Errorf("expected about 800 squares out of 1000, got %d", squares)
+	}
+
+	// Zero weights are never picked, even alongside unweighted impls.
+	f = New().InterfaceImpls(shapeType, &testRect{}).
+		InterfaceImplsWeighted(shapeType, []interface{}{testSquare{}}, []int{0})
+	for i := 0; i < 100; i++ {
+		var s testShape
+		f.GreenRun(&s)
+		if _, ok := s.(*testRect); !ok {
+			t.Fatalf("expected only rects, got %T", s)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for mismatched lengths")
+		}
+	}()
+	New().InterfaceImplsWeighted(shapeType, []interface{}{testSquare{}}, []int{1, 2})
+}
+
+func TestContinue_SortedKeys(t *testing.T) {
+	f := New()
+	c := Continue{fc: &greenrunerContext{greenruner: f}, Rand: f.r}
+
+	strs := c.SortedKeys(map[string]int{"b": 1, "c": 2, "a": 3})
+	ints := c.SortedKeys(map[int8]bool{3: true, -1: true, 0: true})
+	uints := c.SortedKeys(map[uint]bool{30: true, 1: true, 7: true})
+	var got []interface{}
+	for _, keys := range [][]reflect.Value{strs, ints, uints} {
+		for _, k := range keys {
+			got = append(got, k.Interface())
+		}
+	}
+	want := []interface{}{"a", "b", "c", int8(-1), int8(0), int8(3), uint(1), uint(7), uint(30)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for unsortable keys")
+		}
+	}()
+	c.SortedKeys(map[[2]int]bool{})
+}
+
+func TestGreenRunner_SkipJSONOmitted(t *testing.T) {
+	obj := &struct {
+		Kept     int `json:"kept"`
+		Hidden   int `json:"-"`
+		Tagged   int `json:"-" greenrun:"range=1:5"`
+		DashName int `json:"-,"`
+	}{}
+
+	f := New().SkipJSONOmitted(true)
+	for i := 0; i < 20; i++ {
+		f.GreenRun(obj)
+		if obj.Hidden != 0 || obj.Tagged != 0 {
+			t.Fatalf("expected json:\"-\" fields to be skipped, got %+v", obj)
+		}
+	}
+	if obj.Kept == 0 || obj.DashName == 0 {
+		t.Errorf("expected other fields to be filled, got %+v", obj)
+	}
+
+	New().GreenRun(obj)
+	if obj.Hidden == 0 {
+		t.Errorf("expected json:\"-\" fields to be filled by default")
+	}
+}
+
+type testColor string
+
+func TestGreenRunner_Enum(t *testing.T) {
+	type level int
+	obj := &struct {
+		C  testColor
+		L  level
+		Cs []testColor
+	}{}
+
+	f := New().NilChance(0).
+		Enum(testColor(""), testColor("red"), testColor("green")).
+		Enum(level(0), level(1), level(5), level(10))
+	seen := map[testColor]bool{}
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		for _, c := range append(obj.Cs, obj.C) {
+			if c != "red" && c != "green" {
+				t.Fatalf("unexpected color %q", c)
+			}
+			seen[c] = true
+		}
+		if obj.L != 1 && obj.L != 5 && obj.L != 10 {
+			t.Fatalf("unexpected level %v", obj.L)
+		}
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected every color, got %v", seen)
+	}
+
+	// Slices of byte types are otherwise filled in bulk.
+	type octet byte
+	octets := make([]octet, 50)
+	New().Enum(octet(0), octet(1), octet(2)).PreserveLength(true).GreenRun(&octets)
+	for i, o := range octets {
+		if o != 1 && o != 2 {
+			t.Fatalf("unexpected octet %v at %d", o, i)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for a value of the wrong type")
+		}
+	}()
+	f.Enum(testColor(""), "blue")
+}
+
+func TestGreenRunner_AliasChance(t *testing.T) {
+	type node struct {
+		N int
+	}
+	obj := &struct {
+		A, B *node
+		Cs   []*node
+	}{}
+
+	f := New().NilChance(0).NumElements(3, 3)
+	for i := 0; i < 20; i++ {
+		f.GreenRun(obj)
+		if obj.A == obj.B {
+			t.Fatalf("expected distinct pointers by default")
+		}
+	}
+
+	f.AliasChance(1)
+	f.GreenRun(obj)
+	if obj.A != obj.B {
+		t.Errorf("expected B to alias A")
+	}
+	for _, c := range obj.Cs {
+		if c != obj.A {
+			t.Errorf("expected every element to alias A")
+		}
+	}
+
+	f.AliasChance(.5)
+	sawAlias, sawDistinct := false, false
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		sawAlias = sawAlias || obj.A == obj.B
+		sawDistinct = sawDistinct || obj.A != obj.B
+	}
+	if !sawAlias || !sawDistinct {
+		t.Errorf("expected both aliased and distinct pointers, got alias=%v distinct=%v", sawAlias, sawDistinct)
+	}
+
+	// A pointer never aliases a value enclosing it, so lists end.
+	type list struct {
+		V    int
+		Next *list
+	}
+	f = New().NilChance(0).MaxDepth(20).AliasChance(.5)
+	for i := 0; i < 200; i++ {
+		var l list
+		f.GreenRun(&l)
+		seen := map[*list]bool{}
+		for n := &l; n != nil; n = n.Next {
+			if seen[n] {
+				t.Fatalf("expected no cycles, got one in run %d", i)
+			}
+			seen[n] = true
+		}
+	}
+}
+
+func TestGreenRun_lenTag(t *testing.T) {
+	obj := &struct {
+		Name        string `greenrun:"len=3:8"`
+		Description string `greenrun:"len=50:60"`
+		Other       string
+	}{}
+
+	f := New().StringLen(0, 1)
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		if n := utf8.RuneCountInString(obj.Name); n < 3 || n > 8 {
+			t.Errorf("expected Name to have 3 to 8 runes, got %d", n)
+		}
+		if n := utf8.RuneCountInString(obj.Description); n < 50 || n > 60 {
+			t.Errorf("expected Description to have 50 to 60 runes, got %d", n)
+		}
+		if n := utf8.RuneCountInString(obj.Other); n > 1 {
+			t.Errorf("expected Other to follow StringLen, got %d runes", n)
+		}
+	}
+
+	for _, bad := range []interface{}{
+		&struct {
+			A string `greenrun:"len=8:3"`
+		}{},
+		&struct {
+			A string `greenrun:"len=3"`
+		}{},
+		&struct {
+			A int `greenrun:"len=1:2"`
+		}{},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("expected a panic for %T", bad)
+				} else if !strings.Contains(fmt.Sprint(r), ".A") {
+					t.Errorf("expected the panic to name the field, got %v", r)
+				}
+			}()
+			f.GreenRun(bad)
+		}()
+	}
+}
+
+func TestGreenRunner_ASCIIKeys(t *testing.T) {
+	type Key string
+	obj := &struct {
+		M map[string]string
+		N map[Key]int
+	}{}
+
+	f := New().NilChance(0).NumElements(5, 5).ASCIIKeys(true)
+	sawNonASCIIValue := false
+	for i := 0; i < 20; i++ {
+		f.GreenRun(obj)
+		for k, v := range obj.M {
+			for _, c := range k {
+				if c < ' ' || c > '~' {
+					t.Errorf("expected a printable ASCII key, got %q", k)
+				}
+			}
+			for _, c := range v {
+				if c > '~' {
+					sawNonASCIIValue = true
+				}
+			}
+		}
+		for k := range obj.N {
+			for _, c := range k {
+				if c < ' ' || c > '~' {
+					t.Errorf("expected a printable ASCII key, got %q", k)
+				}
+			}
+		}
+	}
+	if !sawNonASCIIValue {
+		t.Errorf("expected map values to be unaffected by ASCIIKeys")
+	}
+}
+
+func TestGreenRunner_Reset(t *testing.T) {
+	type obj struct {
+		A int
+		B string
+		C map[string]float64
+	}
+	f := New().NilChance(0).Funcs(func(p *float64, c Continue) { *p = float64(c.Intn(10)) })
+
+	var first, second obj
+	f.Reset(42).GreenRun(&first)
+	f.GreenRun(&obj{})
+	if f.Reset(42).GreenRun(&second); !reflect.DeepEqual(first, second) {
+		t.Errorf("expected the same object after Reset, got %#v and %#v", first, second)
+	}
+	if f.Seed() != 42 {
+		t.Errorf("expected Seed to return 42, got %v", f.Seed())
+	}
+
+	var fresh obj
+	NewWithSeed(42).NilChance(0).Funcs(func(p *float64, c Continue) { *p = float64(c.Intn(10)) }).GreenRun(&fresh)
+	if !reflect.DeepEqual(first, fresh) {
+		t.Errorf("expected Reset(42) to match NewWithSeed(42), got %#v and %#v", first, fresh)
+	}
+}
+
+func TestGreenRunner_MaxRecursionPerType(t *testing.T) {
+	type Tree struct {
+		Value    int
+		Children []Tree
+		Parent   *Tree
+		Named    map[string]Tree
+	}
+	var depth func(t Tree) int
+	depth = func(t Tree) int {
+		d := 0
+		for _, c := range t.Children {
+			if cd := depth(c); cd > d {
+				d = cd
+			}
+		}
+		for _, c := range t.Named {
+			if cd := depth(c); cd > d {
+				d = cd
+			}
+		}
+		if t.Parent != nil {
+			if pd := depth(*t.Parent); pd > d {
+				d = pd
+			}
+		}
+		return d + 1
+	}
+
+	f := New().NilChance(0).NumElements(3, 3).MaxRecursionPerType(reflect.TypeOf(Tree{}), 3)
+	for i := 0; i < 10; i++ {
+		var tree Tree
+		f.GreenRun(&tree)
+		if d := depth(tree); d != 3 {
+			t.Errorf("expected a tree 3 levels deep, got %d", d)
+		}
+	}
+
+	var leaf Tree
+	New().NilChance(0).MaxRecursionPerType(reflect.TypeOf(Tree{}), 1).GreenRun(&leaf)
+	if leaf.Children == nil || len(leaf.Children) != 0 || leaf.Parent != nil || leaf.Named == nil || len(leaf.Named) != 0 {
+		t.Errorf("expected empty collections and nil pointers, got %#v", leaf)
+	}
+}
+
+func TestGreenRun_definedByteTypes(t *testing.T) {
+	type Blob []byte
+	type ID [16]byte
+	type Octet byte
+
+	// Defined types take the same paths as their underlying types, so they
+	// get the same values for the same seed.
+	var blob Blob
+	var plain []byte
+	NewWithSeed(7).NilChance(0).NumElements(32, 32).GreenRun(&blob)
+	NewWithSeed(7).NilChance(0).NumElements(32, 32).GreenRun(&plain)
+	if len(blob) != 32 || !bytes.Equal(blob, plain) {
+		t.Errorf("expected Blob to be filled like []byte, got %x and %x", blob, plain)
+	}
+
+	var octets []Octet
+	NewWithSeed(7).NilChance(0).NumElements(32, 32).GreenRun(&octets)
+	for i := range octets {
+		if byte(octets[i]) != plain[i] {
+			t.Errorf("expected []Octet to be filled like []byte, got %v and %x", octets, plain)
+			break
+		}
+	}
+
+	var id ID
+	var arr [16]byte
+	NewWithSeed(7).GreenRun(&id)
+	NewWithSeed(7).GreenRun(&arr)
+	if id != ID(arr) || id == (ID{}) {
+		t.Errorf("expected ID to be filled like [16]byte, got %x and %x", id, arr)
+	}
+
+	// Custom functions for the element type still apply.
+	f := New().NilChance(0).Funcs(func(o *Octet, c Continue) { *o = 42 })
+	f.GreenRun(&octets)
+	for _, o := range octets {
+		if o != 42 {
+			t.Errorf("expected the custom function to fill every Octet, got %v", octets)
+			break
+		}
+	}
+}
+
+type neverNilConfig struct {
+	Name string
+}
+
+func (*neverNilConfig) NeverNil() {}
+
+type neverNilTags []string
+
+func (neverNilTags) NeverNil() {}
+
+func TestGreenRunner_NeverNil(t *testing.T) {
+	obj := &struct {
+		Config   *neverNilConfig
+		Tags     neverNilTags
+		Labels   map[string]string
+		Optional *int
+	}{}
+
+	f := New().NilChance(1).NeverNil(reflect.TypeOf(map[string]string{}))
+	for i := 0; i < 20; i++ {
+		f.GreenRun(obj)
+		if obj.Config == nil {
+			t.Errorf("expected a pointer to a NeverNiler to be filled")
+		}
+		if obj.Tags == nil {
+			t.Errorf("expected a NeverNiler slice to be filled")
+		}
+		if obj.Labels == nil {
+			t.Errorf("expected a map registered with NeverNil to be filled")
+		}
+		if obj.Optional != nil {
+			t.Errorf("expected other pointers to follow NilChance, got %v", *obj.Optional)
+		}
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("expected NeverNil to reject a struct type")
+			}
+		}()
+		New().NeverNil(reflect.TypeOf(neverNilConfig{}))
+	}()
+}
+
+func TestGreenRunner_GreenRunStats(t *testing.T) {
+	type inner struct {
+		N int
+	}
+	obj := &struct {
+		P *int
+		L []inner
+		M map[string]*inner
+		T time.Time
+	}{}
+
+	stats := New().NilChance(0).NumElements(2, 2).GreenRunStats(obj)
+	want := Stats{
+		NilsGenerated:     0,
+		ElementsGenerated: 4,
+		MaxDepthReached:   5, // obj, M, M[key], *M[key], N
+		CustomFuncCalls:   1, // T
+	}
+	if stats != want {
+		t.Errorf("expected %+v, got %+v", want, stats)
+	}
+
+	stats = New().NilChance(1).GreenRunStats(obj)
+	if stats.NilsGenerated != 3 || stats.ElementsGenerated != 0 {
+		t.Errorf("expected 3 nils and no elements, got %+v", stats)
+	}
+
+	stats = New().NilChance(0).LeavesOnly(true).GreenRunStats(obj)
+	if stats.NilsGenerated != 3 {
+		t.Errorf("expected 3 nils with LeavesOnly, got %+v", stats)
+	}
+
+	other := &struct {
+		Fn  func() int
+		Raw json.RawMessage
+	}{}
+	stats = New().NilChance(1).GreenRunFuncs(true).GreenRunStats(other)
+	if stats.NilsGenerated != 2 {
+		t.Errorf("expected a nil func and a nil json.RawMessage, got %+v", stats)
+	}
+}
+
+func TestGreenRunner_GreenRunRawPointers(t *testing.T) {
+	obj := &struct {
+		Uptr uintptr
+		P    unsafe.Pointer
+	}{}
+
+	f := New().NonZero(true).EdgeCaseChance(.5)
+	for i := 0; i < 20; i++ {
+		f.GreenRun(obj)
+		if obj.Uptr != 0 || obj.P != nil {
+			t.Errorf("expected raw pointers to be left zero by default, got %v and %v", obj.Uptr, obj.P)
+		}
+	}
+
+	failed := map[string]int{}
+	f.GreenRunRawPointers(true)
+	for i := 0; i < 10; i++ {
+		f.GreenRun(obj)
+		if obj.Uptr == 0 {
+			failed["uptr"]++
+		}
+		if obj.P == nil {
+			t.Errorf("expected unsafe.Pointer to be filled")
+		} else {
+			_ = *(*uint64)(obj.P)
+		}
+	}
+	checkFailed(t, failed)
+}
+
+func TestGreenRunner_RespectValidateTags(t *testing.T) {
+	type obj struct {
+		Age     int               `validate:"required,min=18,max=130"`
+		Score   float64           `validate:"min=0,max=1"`
+		Level   uint8             `validate:"max=3"`
+		Code    string            `validate:"len=6"`
+		Name    string            `validate:"min=2,max=4"`
+		Color   string            `validate:"oneof=red green blue"`
+		Port    int               `validate:"oneof=80 443"`
+		Tags    []string          `validate:"min=1,max=3,dive,len=100"`
+		Attrs   map[string]string `validate:"len=2"`
+		Ignored string            `validate:"email"`
+		Tagged  int               `validate:"min=5" greenrun:"range=1:2"`
+	}
+
+	f := New().NilChance(1).RespectValidateTags(true)
+	for i := 0; i < 50; i++ {
+		var o obj
+		f.GreenRun(&o)
+		if o.Age < 18 || o.Age > 130 {
+			t.Errorf("expected Age within [18, 130], got %v", o.Age)
+		}
+		if o.Score < 0 || o.Score > 1 {
+			t.Errorf("expected Score within [0, 1], got %v", o.Score)
+		}
+		if o.Level > 3 {
+			t.Errorf("expected Level at most 3, got %v", o.Level)
+		}
+		if n := utf8.RuneCountInString(o.Code); n != 6 {
+			t.Errorf("expected Code to have 6 runes, got %d", n)
+		}
+		if n := utf8.RuneCountInString(o.Name); n < 2 || n > 4 {
+			t.Errorf("expected Name to have 2 to 4 runes, got %d", n)
+		}
+		if o.Color != "red" && o.Color != "green" && o.Color != "blue" {
+			t.Errorf("expected Color to be one of red, green or blue, got %q", o.Color)
+		}
+		if o.Port != 80 && o.Port != 443 {
+			t.Errorf("expected Port to be 80 or 443, got %v", o.Port)
+		}
+		if len(o.Tags) < 1 || len(o.Tags) > 3 {
+			t.Errorf("expected 1 to 3 Tags despite NilChance, got %v", o.Tags)
+		}
+		if len(o.Attrs) != 2 {
+			t.Errorf("expected 2 Attrs, got %v", o.Attrs)
+		}
+		if o.Tagged < 1 || o.Tagged > 2 {
+			t.Errorf("expected the greenrun tag to take precedence, got %v", o.Tagged)
+		}
+	}
+
+	words := &struct {
+		Short string `validate:"max=3"`
+		Exact string `validate:"len=5"`
+		Long  string `validate:"min=4"`
+	}{}
+	f = New().RespectValidateTags(true).Wordlist([]string{"hello", "world"}).PrintableStrings(true).MaxStringBytes(12)
+	for i := 0; i < 50; i++ {
+		f.GreenRun(words)
+		if n := utf8.RuneCountInString(words.Short); n > 3 {
+			t.Errorf("expected Short to have at most 3 runes, got %q", words.Short)
+		}
+		if n := utf8.RuneCountInString(words.Exact); n != 5 {
+			t.Errorf("expected Exact to have 5 runes, got %q", words.Exact)
+		}
+		if n := utf8.RuneCountInString(words.Long); n < 4 {
+			t.Errorf("expected Long to have at least 4 runes, got %q", words.Long)
+		}
+	}
+
+	var o obj
+	New().RespectValidateTags(false).NilChance(1).GreenRun(&o)
+	if o.Tags != nil {
+		t.Errorf("expected validate tags to be ignored by default, got %v", o.Tags)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), ".A") {
+				t.Errorf("expected a panic naming the field, got %v", r)
+			}
+		}()
+		New().RespectValidateTags(true).GreenRun(&struct {
+			A int8 `validate:"max=1000"`
+		}{})
+	}()
+}
+
+func TestContinue_Split(t *testing.T) {
+	type shard struct {
+		Values []string
+	}
+	type sharded struct {
+		Shards [8]shard
+	}
+
+	fill := func(seed int64) sharded {
+		f := NewWithSeed(seed).NilChance(0).Funcs(func(s *sharded, c Continue) {
+			var wg sync.WaitGroup
+			for i := range s.Shards {
+				wg.Add(1)
+				go func(shard *shard, c Continue) {
+					defer wg.Done()
+					c.GreenRun(shard)
+					shard.Values = append(shard.Values, c.RandString())
+				}(&s.Shards[i], c.Split())
+			}
+			wg.Wait()
+		})
+		var s sharded
+		f.GreenRun(&s)
+		return s
+	}
+
+	first := fill(5)
+	for _, shard := range first.Shards {
+		if len(shard.Values) < 2 {
+			t.Errorf("expected every shard to be filled, got %v", shard.Values)
+		}
+	}
+	if second := fill(5); !reflect.DeepEqual(first, second) {
+		t.Errorf("expected the same values for the same seed, got %v and %v", first, second)
+	}
+	if first.Shards[0].Values[0] == first.Shards[1].Values[0] {
+		t.Errorf("expected split Continues to generate different values, got %q twice", first.Shards[0].Values[0])
+	}
+
+	// With NewFromBytes, each split Continue takes part of the data left.
+	data := make([]byte, 64)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	f := NewFromBytes(data)
+	f.Funcs(func(s *[2]uint64, c Continue) {
+		left := f.Remaining()
+		split := c.Split()
+		if got := f.Remaining() + split.fc.greenruner.Remaining(); got != left {
+			t.Errorf("expected the %d bytes left to be partitioned, got %d", left, got)
+		}
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s[1] = split.Uint64()
+		}()
+		s[0] = c.Uint64()
+		wg.Wait()
+	})
+	var pair [2]uint64
+	f.GreenRun(&pair)
+	if pair[0] == pair[1] {
+		t.Errorf("expected split Continues to read different bytes, got %#x twice", pair[0])
+	}
+
+	// Errors in a split Continue are returned by its GreenRunE, and its
+	// GreenRun panics with them rather than the error of the whole run.
+	f = New().Funcs(func(s *shard, c Continue) {
+		split := c.Split()
+		var fn func()
+		err := split.GreenRunE(&fn)
+		if err == nil {
+			t.Errorf("expected an error for a func")
+		}
+		defer func() {
+			if e, ok := recover().(error); !ok || e.Error() != err.Error() {
+				t.Errorf("expected GreenRun to panic with %v, got %v", err, e)
+			}
+		}()
+		split.GreenRun(&fn)
+	})
+	f.GreenRun(&shard{})
+}
+
+func TestContinue_GreenRunE(t *testing.T) {
+	type holder struct {
+		N int
+	}
+	obj := &struct {
+		H holder
+	}{}
+
+	var err error
+	var path string
+	New().Funcs(func(h *holder, c Continue) {
+		bad := &struct {
+			N int `greenrun:"range=5:1"`
+		}{}
+		err = c.GreenRunE(bad)
+		path = c.Path()
+	}).GreenRun(obj)
+	if err == nil {
+		t.Errorf("expected an error for a bad tag")
+	}
+	if path != ".H" {
+		t.Errorf("expected the path to be restored after an error, got %q", path)
+	}
+}
+
+func TestGreenRunner_NilInterfaces(t *testing.T) {
+	obj := &struct {
+		R   io.Reader
+		Any interface{}
+		S   fmt.Stringer
+		N   int
+	}{}
+
+	if err := New().GreenRunE(obj); err == nil {
+		t.Errorf("expected an error for interfaces without implementations by default")
+	}
+
+	obj.R = strings.NewReader("x")
+	f := New().NilInterfaces(true).InterfaceImpls(reflect.TypeOf((*fmt.Stringer)(nil)).Elem(), net.IP{})
+	stats := f.GreenRunStats(obj)
+	if obj.R != nil || obj.Any != nil {
+		t.Errorf("expected interfaces without implementations to be nil, got %v and %v", obj.R, obj.Any)
+	}
+	if _, ok := obj.S.(net.IP); !ok {
+		t.Errorf("expected registered implementations to still be used, got %#v", obj.S)
+	}
+	if stats.NilsGenerated != 2 {
+		t.Errorf("expected 2 nils to be counted, got %+v", stats)
+	}
+}
+
+type genericBox[T any] struct {
+	Value T
+	Items []T
+}
+
+func TestGreenRun_genericTypes(t *testing.T) {
+	obj := &struct {
+		Ints     genericBox[int]
+		Int64s   genericBox[int64]
+		Strings  genericBox[string]
+		Stringer genericBox[fmt.Stringer]
+		Reader   genericBox[io.Reader]
+	}{}
+
+	f := New().NilChance(0).Funcs(
+		func(b *genericBox[int], c Continue) {
+			b.Value = 42
+		},
+		func(b *genericBox[io.Reader], c Continue) {
+			b.Value = strings.NewReader("scoped to this instantiation")
+		},
+	).InterfaceImpls(reflect.TypeOf((*fmt.Stringer)(nil)).Elem(), time.Duration(0))
+	for i := 0; i < 10; i++ {
+		f.GreenRun(obj)
+		if obj.Ints.Value != 42 || obj.Ints.Items != nil {
+			t.Errorf("expected the function for genericBox[int] to be used, got %+v", obj.Ints)
+		}
+		if len(obj.Int64s.Items) == 0 {
+			t.Errorf("expected genericBox[int64] to be greenruned normally, got %+v", obj.Int64s)
+		}
+		if len(obj.Strings.Items) == 0 {
+			t.Errorf("expected genericBox[string] to be greenruned normally, got %+v", obj.Strings)
+		}
+		if _, ok := obj.Stringer.Value.(time.Duration); !ok {
+			t.Errorf("expected the registered implementation, got %#v", obj.Stringer.Value)
+		}
+		if obj.Reader.Value == nil {
+			t.Errorf("expected the function for genericBox[io.Reader] to be used")
+		}
+	}
+}
+
+func TestGreenRunner_With(t *testing.T) {
+	type obj struct {
+		P *int
+		L []string
+		S string
+	}
+
+	f := NewWithSeed(3).NilChance(1)
+	var o obj
+	f.With(WithNilChance(0), WithNumElements(4, 4), WithFuncs(func(s *string, c Continue) { *s = "custom" })).GreenRun(&o)
+	if o.P == nil || len(o.L) != 4 || o.S != "custom" {
+		t.Errorf("expected the options to apply, got %+v", o)
+	}
+
+	o = obj{}
+	f.GreenRun(&o)
+	if o.P != nil || o.L != nil || o.S == "custom" {
+		t.Errorf("expected f to be untouched, got %+v", o)
+	}
+
+	// The copy is a Clone, so the same seed gives the same values.
+	var a, b [2]int
+	f1, f2 := NewWithSeed(9), NewWithSeed(9)
+	f1.With(WithMaxDepth(5)).GreenRun(&a[0])
+	f1.GreenRun(&a[1])
+	f2.Clone().GreenRun(&b[0])
+	f2.GreenRun(&b[1])
+	if a != b {
+		t.Errorf("expected With to draw from f as Clone does, got %v and %v", a, b)
+	}
+
+	// A shared GreenRunner can be tuned per call from several goroutines.
+	shared := New().NilChance(0)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			var o obj
+			shared.With(WithNumElements(n, n)).GreenRun(&o)
+			if len(o.L) != n {
+				t.Errorf("expected %d elements, got %d", n, len(o.L))
+			}
+		}(i + 1)
+	}
+	wg.Wait()
+
+	custom := Option(func(f *GreenRunner) { f.StringLen(3, 3) })
+	f.With(custom, WithStringLen(1, 1), WithNilChance(0)).GreenRun(&o)
+	if utf8.RuneCountInString(o.L[0]) != 1 {
+		t.Errorf("expected later options to override earlier ones, got %q", o.L[0])
+	}
+}
+
+func TestGreenRunner_NonNilPointersTo(t *testing.T) {
+	type msg struct {
+		Count  *int32
+		Name   *string
+		Flag   *bool
+		Ratio  *float64
+		Nested *struct{ N int }
+	}
+
+	f := New().NilChance(1).NonNilPointersTo(reflect.Int32, reflect.String, reflect.Bool)
+	for i := 0; i < 20; i++ {
+		var m msg
+		f.GreenRun(&m)
+		if m.Count == nil || m.Name == nil || m.Flag == nil {
+			t.Errorf("expected pointers to the given kinds to be filled, got %+v", m)
+		}
+		if m.Ratio != nil || m.Nested != nil {
+			t.Errorf("expected other pointers to follow NilChance, got %+v", m)
+		}
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("expected NonNilPointersTo to reject reflect.Struct")
+			}
+		}()
+		New().NonNilPointersTo(reflect.Struct)
+	}()
+}
+
+func TestGreenRunner_TimeLocation(t *testing.T) {
+	var tm time.Time
+	f := New()
+	for i := 0; i < 10; i++ {
+		f.GreenRun(&tm)
+		if tm.Location() != time.UTC {
+			t.Errorf("expected UTC by default, got %v", tm.Location())
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, tm.Format(time.RFC3339Nano))
+		if err != nil || parsed != tm {
+			t.Errorf("expected %v to survive an RFC3339Nano round trip, got %v, %v", tm, parsed, err)
+		}
+	}
+
+	// A time.Now() bound carries a monotonic reading, which mustn't leak.
+	now := time.Now()
+	f.TimeRange(now, now)
+	f.GreenRun(&tm)
+	if tm != now.Round(0).UTC() {
+		t.Errorf("expected %v without a monotonic reading, got %v", now.Round(0).UTC(), tm)
+	}
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	f.TimeLocation(tokyo).GreenRun(&tm)
+	if tm.Location() != tokyo {
+		t.Errorf("expected the location to be JST, got %v", tm.Location())
+	}
+}
+
+func TestGreenRunner_DeterministicStreams(t *testing.T) {
+	type label string
+	type inner struct {
+		X, Y int
+	}
+	type obj struct {
+		A int
+		B label
+		C []inner
+		D string
+	}
+
+	var plain, custom, again obj
+	NewWithSeed(11).NilChance(0).DeterministicStreams(true).GreenRun(&plain)
+	NewWithSeed(11).NilChance(0).DeterministicStreams(true).Funcs(func(l *label, c Continue) {
+		*l = "fixed"
+	}).GreenRun(&custom)
+	NewWithSeed(11).NilChance(0).DeterministicStreams(true).GreenRun(&again)
+
+	if !reflect.DeepEqual(plain, again) {
+		t.Errorf("expected the same values for the same seed, got %+v and %+v", plain, again)
+	}
+	if custom.B != "fixed" {
+		t.Errorf("expected the custom function to be used, got %q", custom.B)
+	}
+	custom.B = plain.B
+	if !reflect.DeepEqual(plain, custom) {
+		t.Errorf("expected the other fields to be unaffected by the custom function, got %+v and %+v", plain, custom)
+	}
+
+	// Without it, the custom function not consuming randomness shifts the
+	// values of the fields after it.
+	NewWithSeed(11).NilChance(0).GreenRun(&plain)
+	NewWithSeed(11).NilChance(0).Funcs(func(l *label, c Continue) {
+		*l = "fixed"
+	}).GreenRun(&custom)
+	if plain.D == custom.D {
+		t.Errorf("expected D to differ without DeterministicStreams, got %q twice", plain.D)
+	}
+
+	f := NewWithSeed(11).DeterministicStreams(true)
+	f.GreenRun(&plain)
+	f.GreenRun(&again)
+	if reflect.DeepEqual(plain, again) {
+		t.Errorf("expected successive calls to generate different values, got %+v twice", plain)
+	}
+
+	// Struct keys share a path, but not a stream.
+	type pk struct {
+		ID   int
+		Name string
+	}
+	var m map[pk]int
+	NewWithSeed(11).NilChance(0).NumElements(5, 5).DeterministicStreams(true).GreenRun(&m)
+	if len(m) != 5 {
+		t.Errorf("expected 5 distinct struct keys, got %v", m)
+	}
+
+	// Nor do the results of successive calls to a generated func.
+	var fn func() inner
+	NewWithSeed(11).GreenRunFuncs(true).NilChance(0).DeterministicStreams(true).GreenRun(&fn)
+	if a, b := fn(), fn(); a == b {
+		t.Errorf("expected successive calls to return different values, got %+v twice", a)
+	}
+}
+
+func TestGreenRun_mapOfInterfaces(t *testing.T) {
+	obj := &struct {
+		Config map[string]interface{}
+		Named  map[string]fmt.Stringer
+	}{}
+
+	f := NewWithSeed(1).NilChance(0).NumElements(10, 10).
+		AnyTypes(reflect.TypeOf(0), reflect.TypeOf(""), reflect.TypeOf([]bool{})).
+		InterfaceImpls(reflect.TypeOf((*fmt.Stringer)(nil)).Elem(), time.Duration(0), net.IP{})
+	f.GreenRun(obj)
+
+	types := map[reflect.Type]bool{}
+	for k, v := range obj.Config {
+		if v == nil {
+			t.Errorf("expected Config[%q] to be filled", k)
+			continue
+		}
+		types[reflect.TypeOf(v)] = true
+	}
+	if len(obj.Config) != 10 || len(types) < 2 {
+		t.Errorf("expected 10 values of varied types, got %v", obj.Config)
+	}
+
+	types = map[reflect.Type]bool{}
+	for k, v := range obj.Named {
+		if v == nil {
+			t.Errorf("expected Named[%q] to be filled", k)
+			continue
+		}
+		types[reflect.TypeOf(v)] = true
+	}
+	if len(types) != 2 {
+		t.Errorf("expected both registered implementations, got %v", obj.Named)
+	}
+}
+
+func TestGreenRunner_FloatDecimalSafe(t *testing.T) {
+	obj := &struct {
+		F64 []float64
+		F32 []float32
+	}{}
+
+	for _, f := range []*GreenRunner{
+		New().FloatDecimalSafe(true),
+		New().FloatDecimalSafe(true).FloatMode(FloatFull),
+		New().FloatDecimalSafe(true).FloatMode(FloatFinite).EdgeCaseChance(.5),
+		New().FloatDecimalSafe(true).FloatRange(-1e30, 1e30),
+	} {
+		for i := 0; i < 20; i++ {
+			f.NilChance(0).NumElements(20, 20).GreenRun(obj)
+			out, err := json.Marshal(obj)
+			if err != nil {
+				t.Fatalf("expected floats JSON can marshal, got %v", err)
+			}
+			if strings.ContainsAny(string(out), "eE") {
+				t.Fatalf("expected plain decimal notation, got %s", out)
+			}
+		}
+	}
+
+	var spread [2]bool
+	f := New().FloatDecimalSafe(true).FloatMode(FloatFull)
+	for i := 0; i < 100; i++ {
+		var x float64
+		f.GreenRun(&x)
+		spread[0] = spread[0] || math.Abs(x) < 1
+		spread[1] = spread[1] || math.Abs(x) > 1e10
+	}
+	if !spread[0] || !spread[1] {
+		t.Errorf("expected magnitudes spread over the allowed range, got %v", spread)
+	}
+}
+
+func TestGreenRunner_PreserveLength(t *testing.T) {
+	corpus := make([]string, 50)
+	data := make([]byte, 32)
+	obj := &struct {
+		Items []int
+		Nil   []int
+	}{Items: make([]int, 7)}
+
+	f := New().NilChance(0).NumElements(1, 3).PreserveLength(true)
+	f.GreenRun(&corpus)
+	f.GreenRun(&data)
+	f.GreenRun(obj)
+	if len(corpus) != 50 || len(data) != 32 || len(obj.Items) != 7 {
+		t.Errorf("expected lengths to be preserved, got %d, %d and %d", len(corpus), len(data), len(obj.Items))
+	}
+	if len(obj.Nil) < 1 || len(obj.Nil) > 3 {
+		t.Errorf("expected a nil slice to be made as usual, got %v", obj.Nil)
+	}
+	empty := 0
+	for _, s := range corpus {
+		if s == "" {
+			empty++
+		}
+	}
+	if empty > 10 || bytes.Equal(data, make([]byte, 32)) {
+		t.Errorf("expected the elements to be filled, got %q and %x", corpus, data)
+	}
+
+	corpus = make([]string, 50)
+	New().NumElements(1, 3).GreenRun(&corpus)
+	if len(corpus) > 3 {
+		t.Errorf("expected the length to be regenerated by default, got %d", len(corpus))
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package greenrun
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// regexpMaxRepeat bounds how many times unbounded repetitions, such as x* or
+// x{2,}, are repeated when generating a string matching a regexp.
+const regexpMaxRepeat = 10
+
+// regexpCache maps patterns to their parsed *syntax.Regexp, or to the error
+// from parsing them.
+var regexpCache sync.Map
+
+// parseRegexp parses pattern, using Perl syntax like package regexp, and
+// caches the result.
+func parseRegexp(pattern string) (*syntax.Regexp, error) {
+	if cached, ok := regexpCache.Load(pattern); ok {
+		if err, ok := cached.(error); ok {
+			return nil, err
+		}
+		return cached.(*syntax.Regexp), nil
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		regexpCache.Store(pattern, err)
+		return nil, err
+	}
+	re = re.Simplify()
+	regexpCache.Store(pattern, re)
+	return re, nil
+}
+
+// RandMatching returns a random string matching the regular expression
+// pattern, in the syntax of package regexp. Characters matched by . are
+// picked from the GreenRunner's charset. Zero-width assertions such as ^, $
+// and \b are ignored, so patterns that depend on them to match may produce
+// strings that don't. It returns an error if pattern is invalid, or if no
+// string can match it, as with an empty character class, or . when the
+// charset holds only newlines.
+func (c Continue) RandMatching(pattern string) (string, error) {
+	re, err := parseRegexp(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid regexp %q: %w", pattern, err)
+	}
+	if !matchable(re) {
+		return "", fmt.Errorf("no string matches regexp %q", pattern)
+	}
+	var sb strings.Builder
+	if err := c.fc.greenruner.genMatching(&sb, c.Rand, re); err != nil {
+		return "", fmt.Errorf("regexp %q: %w", pattern, err)
+	}
+	return sb.String(), nil
+}
+
+// matchable reports whether any string matches re, ignoring the charset.
+func matchable(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return false
+	case syntax.OpCharClass:
+		return len(re.Rune) > 0
+	case syntax.OpCapture, syntax.OpPlus:
+		return matchable(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min == 0 || matchable(re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !matchable(sub) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if matchable(sub) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// genMatching appends to sb a random string matching re, which must be
+// matchable. It returns an error if the charset has no character for . to
+// match.
+func (f *GreenRunner) genMatching(sb *strings.Builder, r *rand.Rand, re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, c := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 && randBool(r) {
+				c = unicode.SimpleFold(c)
+			}
+			sb.WriteRune(c)
+		}
+	case syntax.OpCharClass:
+		sb.WriteRune(randClassRune(r, re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		c := f.charset[r.Intn(len(f.charset))].choose(r, f.assignedRunes)
+		for tries := 1; re.Op == syntax.OpAnyCharNotNL && c == '\n'; tries++ {
+			if tries == chooseAttempts {
+				return fmt.Errorf("no character other than newline found in the charset in %d attempts", chooseAttempts)
+			}
+			c = f.charset[r.Intn(len(f.charset))].choose(r, f.assignedRunes)
+		}
+		sb.WriteRune(c)
+	case syntax.OpCapture:
+		return f.genMatching(sb, r, re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 {
+			max = min + regexpMaxRepeat
+		}
+		if !matchable(re.Sub[0]) {
+			// Only zero repetitions match.
+			max = min
+		}
+		n := min + r.Intn(max-min+1)
+		for i := 0; i < n; i++ {
+			if err := f.genMatching(sb, r, re.Sub[0]); err != nil {
+				return err
+			}
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := f.genMatching(sb, r, sub); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		var subs []*syntax.Regexp
+		for _, sub := range re.Sub {
+			if matchable(sub) {
+				subs = append(subs, sub)
+			}
+		}
+		return f.genMatching(sb, r, subs[r.Intn(len(subs))])
+	}
+	// The remaining ops, such as OpEmptyMatch and OpBeginLine, match the
+	// empty string.
+	return nil
+}
+
+// randClassRune returns a random rune from the character class ranges, given
+// as pairs of inclusive bounds, with every rune equally likely.
+func randClassRune(r *rand.Rand, ranges []rune) rune {
+	var total int64
+	for i := 0; i < len(ranges); i += 2 {
+		total += int64(ranges[i+1]-ranges[i]) + 1
+	}
+	n := r.Int63n(total)
+	for i := 0; i < len(ranges); i += 2 {
+		size := int64(ranges[i+1]-ranges[i]) + 1
+		if n < size {
+			return ranges[i] + rune(n)
+		}
+		n -= size
+	}
+	panic("unreachable")
+}
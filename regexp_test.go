@@ -0,0 +1,105 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package greenrun
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestContinue_RandMatching(t *testing.T) {
+	f := New()
+	c := Continue{fc: &greenrunerContext{greenruner: f}, Rand: f.r}
+	for _, pattern := range []string{
+		`^[a-z]{3,8}@[a-z]+\.(com|org|net)$`,
+		`^SKU-\d{4}-[A-F0-9]{2}$`,
+		`^(?i)hello( world)?$`,
+		`^x*y+z?$`,
+		`^[^a-z]{5}$`,
+		`^.{0,4}$`,
+		`^$`,
+		`^a|[^\x00-\x{10FFFF}]$`,
+		`^b[^\x00-\x{10FFFF}]*$`,
+	} {
+		re := regexp.MustCompile(pattern)
+		for i := 0; i < 20; i++ {
+			s, err := c.RandMatching(pattern)
+			if err != nil {
+				t.Errorf("unexpected error for %v: %v", pattern, err)
+			} else if !re.MatchString(s) {
+				t.Errorf("expected a match for %v, got %q", pattern, s)
+			}
+		}
+	}
+
+	for _, pattern := range []string{
+		`[a-`,
+		`[^\x00-\x{10FFFF}]`,
+		`x[^\x00-\x{10FFFF}]+`,
+	} {
+		if s, err := c.RandMatching(pattern); err == nil {
+			t.Errorf("expected an error for %v, got %q", pattern, s)
+		}
+	}
+
+	// . can't match anything if the charset only holds newlines.
+	f = New().Charset([2]rune{'\n', '\n'})
+	c = Continue{fc: &greenrunerContext{greenruner: f}, Rand: f.r}
+	if s, err := c.RandMatching(`a.`); err == nil {
+		t.Errorf("expected an error for . with only newlines, got %q", s)
+	}
+	if s, err := c.RandMatching(`(?s)a.`); err != nil || s != "a\n" {
+		t.Errorf("expected \"a\\n\" for (?s). with only newlines, got %q, %v", s, err)
+	}
+}
+
+func TestGreenRun_regexpTag(t *testing.T) {
+	obj := &struct {
+		Email string `greenrun:"regexp=^[a-z]{1,5}@example\\.(com|org)$"`
+		Pair  string `greenrun:"regexp=^[0-9]{1,2},[0-9]{1,2}$"`
+	}{}
+
+	email := regexp.MustCompile(`^[a-z]{1,5}@example\.(com|org)$`)
+	pair := regexp.MustCompile(`^[0-9]{1,2},[0-9]{1,2}$`)
+	f := New()
+	for i := 0; i < 50; i++ {
+		f.GreenRun(obj)
+		if !email.MatchString(obj.Email) {
+			t.Errorf("Email doesn't match: %q", obj.Email)
+		}
+		if !pair.MatchString(obj.Pair) {
+			t.Errorf("Pair doesn't match: %q", obj.Pair)
+		}
+	}
+
+	for _, bad := range []interface{}{
+		&struct {
+			A string `greenrun:"regexp=[a-"`
+		}{},
+		&struct {
+			A int `greenrun:"regexp=[0-9]"`
+		}{},
+		&struct {
+			A string `greenrun:"regexp=[^\\x00-\\x{10FFFF}]"`
+		}{},
+	} {
+		if err := f.GreenRunE(bad); err == nil || !strings.Contains(err.Error(), ".A") {
+			t.Errorf("expected an error naming the field for %T, got %v", bad, err)
+		}
+	}
+}
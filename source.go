@@ -0,0 +1,154 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package greenrun
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+)
+
+// NewFromBytes returns a new GreenRunner that draws all of its randomness
+// from data, consuming it from the start, rather than from a pseudo-random
+// generator. Once data is used up, it behaves as if padded with zero bytes.
+// This makes it suitable for use inside a native Go fuzz target, where the
+// fuzzing engine supplies data:
+//
+//	f.Fuzz(func(t *testing.T, data []byte) {
+//		var obj MyType
+//		greenrun.NewFromBytes(data).GreenRun(&obj)
+//		...
+//	})
+//
+// The same data, configuration and type always produce the same value, so
+// inputs found by the engine can be reproduced and minimized. Seed is
+// meaningless for such GreenRunners.
+func NewFromBytes(data []byte) *GreenRunner {
+	src := &byteSource{data: data}
+	f := NewWithSeed(0).RandSource(src)
+	f.bytes = src
+	return f
+}
+
+// NewConsumer is like NewFromBytes, except that once data is used up, maps,
+// slices and channels are left empty, taking precedence over NumElements and
+// NumElementsFor, so that short inputs produce small values rather than ones
+// shaped by zero bytes. It eases migrating fuzz targets written with
+// go-fuzz-headers' ConsumeFuzzer; use Remaining to see how much of data is
+// left.
+func NewConsumer(data []byte) *GreenRunner {
+	f := NewFromBytes(data)
+	f.stopWhenExhausted = true
+	return f
+}
+
+// Remaining returns how many bytes of the data given to NewFromBytes or
+// NewConsumer are yet to be consumed. It returns 0 for other GreenRunners,
+// including those whose RandSource has since been replaced.
+func (f *GreenRunner) Remaining() int {
+	if f.bytes == nil {
+		return 0
+	}
+	return len(f.bytes.data)
+}
+
+// Randomness is a source of uniformly distributed random uint64 values. It
+// is satisfied by the sources in math/rand/v2, such as *rand.PCG and
+// *rand.ChaCha8, as well as by *rand.Rand from either math/rand package.
+type Randomness interface {
+	Uint64() uint64
+}
+
+// RandomnessSource causes f to get values from r, which allows driving it
+// with a math/rand/v2 generator. Custom greenrun functions and Continue still
+// see a math/rand *rand.Rand, backed by r.
+func (f *GreenRunner) RandomnessSource(r Randomness) *GreenRunner {
+	return f.RandSource(randomnessSource{r})
+}
+
+// CryptoRandSource causes f to get values from crypto/rand rather than a
+// seedable generator, for fixtures such as keys and nonces that shouldn't be
+// predictable. The values generated can't be reproduced, and Seed is
+// meaningless afterwards. It is also considerably slower.
+func (f *GreenRunner) CryptoRandSource() *GreenRunner {
+	return f.RandomnessSource(cryptoRandomness{})
+}
+
+// cryptoRandomness is a Randomness reading from crypto/rand.
+type cryptoRandomness struct{}
+
+func (cryptoRandomness) Uint64() uint64 {
+	var buf [8]byte
+	if _, err := cryptorand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+// randomnessSource adapts a Randomness to a rand.Source.
+type randomnessSource struct {
+	Randomness
+}
+
+func (s randomnessSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s randomnessSource) Seed(int64) {
+	panic("greenrun: can't seed a Randomness")
+}
+
+// byteSource is a rand.Source that returns successive 8-byte chunks of data.
+type byteSource struct {
+	data []byte
+}
+
+func (s *byteSource) Uint64() uint64 {
+	var buf [8]byte
+	n := copy(buf[:], s.data)
+	s.data = s.data[n:]
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+func (s *byteSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *byteSource) Seed(int64) {
+	panic("greenrun: can't seed a byte source")
+}
+
+// splitMixSource is a small, fast rand.Source implementing SplitMix64, for
+// sources that are created often, as by DeterministicStreams.
+type splitMixSource struct {
+	state uint64
+}
+
+func (s *splitMixSource) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (s *splitMixSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *splitMixSource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
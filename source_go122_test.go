@@ -0,0 +1,43 @@
+//go:build go1.22
+
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package greenrun
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestGreenRunner_RandomnessSource(t *testing.T) {
+	type obj struct {
+		A int
+		B string
+		C []float64
+	}
+
+	var a, b obj
+	New().RandomnessSource(rand.NewPCG(1, 2)).GreenRun(&a)
+	New().RandomnessSource(rand.NewPCG(1, 2)).GreenRun(&b)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("expected the same source to generate the same value, got %#v and %#v", a, b)
+	}
+
+	var c obj
+	New().RandomnessSource(rand.NewChaCha8([32]byte{})).GreenRun(&c)
+}
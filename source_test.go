@@ -0,0 +1,85 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package greenrun
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestNewFromBytes(t *testing.T) {
+	type obj struct {
+		A int
+		B string
+		C []float64
+		D map[string]*int
+	}
+
+	data := []byte("some fuzz input that drives every random decision")
+	var a, b obj
+	NewFromBytes(data).GreenRun(&a)
+	NewFromBytes(data).GreenRun(&b)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("expected the same data to generate the same value, got %#v and %#v", a, b)
+	}
+
+	// Exhausted input acts as zeros, which must not make GreenRun loop.
+	var c obj
+	NewFromBytes(nil).GreenRun(&c)
+	NewFromBytes([]byte{1, 2, 3}).GreenRun(&c)
+}
+
+func TestNewConsumer(t *testing.T) {
+	data := make([]byte, 64)
+	for i := range data {
+		data[i] = 0xff
+	}
+	f := NewConsumer(data).NilChance(0).NumElements(5, 5)
+	if f.Remaining() != 64 {
+		t.Errorf("expected 64 bytes remaining, got %v", f.Remaining())
+	}
+	var a uint64
+	f.GreenRun(&a)
+	if n := f.Remaining(); n == 0 || n >= 64 {
+		t.Errorf("expected some of the data to be consumed, got %v bytes remaining", n)
+	}
+
+	var s [][]int
+	f.GreenRun(&s)
+	if f.Remaining() != 0 {
+		t.Errorf("expected the data to be used up, got %v bytes remaining", f.Remaining())
+	}
+	f.GreenRun(&s)
+	if len(s) != 0 {
+		t.Errorf("expected no elements once the data is used up, got %v", s)
+	}
+
+	if New().Remaining() != 0 || NewFromBytes(data).RandSource(rand.NewSource(1)).Remaining() != 0 {
+		t.Errorf("expected Remaining to be 0 for GreenRunners not reading bytes")
+	}
+}
+
+func TestGreenRunner_CryptoRandSource(t *testing.T) {
+	f := New().NilChance(0).CryptoRandSource()
+	var a, b [4]uint64
+	f.GreenRun(&a)
+	f.GreenRun(&b)
+	if a == b {
+		t.Errorf("expected different values, got %v twice", a)
+	}
+}
@@ -0,0 +1,355 @@
+/*
+Copyright 2014 Google Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package greenrun
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// fieldTag holds the parsed contents of a struct field's `greenrun` tag.
+type fieldTag struct {
+	// skip is set by `greenrun:"-"`.
+	skip bool
+
+	// hasRange is set by `greenrun:"range=min:max"`. The bounds are kept
+	// as strings, since how they parse depends on the field's kind.
+	hasRange           bool
+	rangeMin, rangeMax string
+
+	// regexp is set by `greenrun:"regexp=pattern"`.
+	regexp string
+
+	// hasLen is set by `greenrun:"len=min:max"`.
+	hasLen         bool
+	minLen, maxLen int
+}
+
+// parseFieldTag parses the `greenrun` tag of sf. Options are separated by
+// commas, except that regexp, whose pattern may itself contain commas, takes
+// up the rest of the tag and so must come last.
+func parseFieldTag(sf reflect.StructField) (fieldTag, error) {
+	var tag fieldTag
+	s, ok := sf.Tag.Lookup("greenrun")
+	if !ok || s == "" {
+		return tag, nil
+	}
+	if s == "-" {
+		tag.skip = true
+		return tag, nil
+	}
+	for s != "" {
+		opt := s
+		if strings.HasPrefix(s, "regexp=") {
+			s = ""
+		} else if i := strings.Index(s, ","); i >= 0 {
+			opt, s = s[:i], s[i+1:]
+		} else {
+			s = ""
+		}
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "range":
+			min, max, ok := strings.Cut(value, ":")
+			if !ok || min == "" || max == "" {
+				return tag, fmt.Errorf("range must look like range=min:max, got %q", value)
+			}
+			tag.hasRange, tag.rangeMin, tag.rangeMax = true, min, max
+		case "len":
+			min, max, ok := strings.Cut(value, ":")
+			lo, err1 := strconv.Atoi(min)
+			hi, err2 := strconv.Atoi(max)
+			if !ok || err1 != nil || err2 != nil || lo < 0 || lo > hi {
+				return tag, fmt.Errorf("len must look like len=min:max, with 0 <= min <= max, got %q", value)
+			}
+			tag.hasLen, tag.minLen, tag.maxLen = true, lo, hi
+		case "regexp":
+			if _, err := parseRegexp(value); err != nil {
+				return tag, fmt.Errorf("invalid regexp %q: %v", value, err)
+			}
+			tag.regexp = value
+		default:
+			return tag, fmt.Errorf("unknown greenrun tag option %q", opt)
+		}
+	}
+	return tag, nil
+}
+
+// fillRange fills the numeric value v, the field described by sf, with a
+// random value within the range given by tag.
+func (fc *greenrunerContext) fillRange(v reflect.Value, sf reflect.StructField, tag fieldTag) {
+	if !v.CanSet() {
+		return
+	}
+	r := fc.greenruner.r
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		min, err1 := strconv.ParseInt(tag.rangeMin, 0, 64)
+		max, err2 := strconv.ParseInt(tag.rangeMax, 0, 64)
+		if err1 != nil || err2 != nil || min > max || v.OverflowInt(min) || v.OverflowInt(max) {
+			fc.fail("invalid range %v:%v for %v", tag.rangeMin, tag.rangeMax, v.Type())
+		}
+		v.SetInt(randInt64Range(r, min, max))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		min, err1 := strconv.ParseUint(tag.rangeMin, 0, 64)
+		max, err2 := strconv.ParseUint(tag.rangeMax, 0, 64)
+		if err1 != nil || err2 != nil || min > max || v.OverflowUint(min) || v.OverflowUint(max) {
+			fc.fail("invalid range %v:%v for %v", tag.rangeMin, tag.rangeMax, v.Type())
+		}
+		v.SetUint(randUint64Range(r, min, max))
+	case reflect.Float32, reflect.Float64:
+		min, err1 := strconv.ParseFloat(tag.rangeMin, 64)
+		max, err2 := strconv.ParseFloat(tag.rangeMax, 64)
+		if err1 != nil || err2 != nil || min > max {
+			fc.fail("invalid range %v:%v for %v", tag.rangeMin, tag.rangeMax, v.Type())
+		}
+		// Interpolate without computing max-min, which may overflow.
+		x := r.Float64()
+		v.SetFloat(min*(1-x) + max*x)
+	default:
+		fc.fail("range is only supported on numeric fields, not %v", v.Type())
+	}
+}
+
+// fillMatching fills the string value v with a random string matching the
+// regexp given by tag.
+func (fc *greenrunerContext) fillMatching(v reflect.Value, tag fieldTag) {
+	if !v.CanSet() {
+		return
+	}
+	if v.Kind() != reflect.String {
+		fc.fail("regexp is only supported on string fields, not %v", v.Type())
+	}
+	s, err := Continue{fc: fc, Rand: fc.greenruner.r}.RandMatching(tag.regexp)
+	if err != nil {
+		fc.fail("%w", err)
+	}
+	v.SetString(s)
+}
+
+// fillLen fills the string value v with a random string whose length is
+// within the bounds given by tag, rather than those set by StringLen.
+func (fc *greenrunerContext) fillLen(v reflect.Value, tag fieldTag) {
+	if !v.CanSet() {
+		return
+	}
+	if v.Kind() != reflect.String {
+		fc.fail("len is only supported on string fields, not %v", v.Type())
+	}
+	v.SetString(fc.greenruner.randStringLen(fc.greenruner.r, tag.minLen, tag.maxLen))
+}
+
+// validateTag holds the constraints GreenRunner understands from a struct
+// field's `validate` tag, as used by github.com/go-playground/validator.
+type validateTag struct {
+	// raw is the whole tag, for error messages.
+	raw string
+
+	// min and max are set by min=, max= and len=, which sets both. They are
+	// kept as strings, since how they parse depends on the field's kind.
+	hasMin, hasMax bool
+	min, max       string
+
+	// oneof is set by oneof=a b c.
+	oneof []string
+}
+
+// any reports whether t holds any constraints.
+func (t validateTag) any() bool {
+	return t.hasMin || t.hasMax || len(t.oneof) > 0
+}
+
+// parseValidateTag parses the constraints GreenRunner supports out of the
+// `validate` tag of sf, ignoring all others. Constraints following dive
+// apply to elements rather than to the field, so they are ignored too.
+func parseValidateTag(sf reflect.StructField) validateTag {
+	tag := validateTag{raw: sf.Tag.Get("validate")}
+	for _, opt := range strings.Split(tag.raw, ",") {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "dive":
+			return tag
+		case "min":
+			tag.hasMin, tag.min = true, value
+		case "max":
+			tag.hasMax, tag.max = true, value
+		case "len":
+			tag.hasMin, tag.min = true, value
+			tag.hasMax, tag.max = true, value
+		case "oneof":
+			tag.oneof = strings.Fields(value)
+		}
+	}
+	return tag
+}
+
+// fillValidated fills the field v with a random value satisfying the
+// constraints given by tag, and reports whether it did. Values
+// of kinds the constraints don't apply to are left for doGreenRun.
+func (fc *greenrunerContext) fillValidated(v reflect.Value, tag validateTag) bool {
+	if !v.CanSet() {
+		return true
+	}
+	f := fc.greenruner
+	invalid := func() {
+		fc.fail("invalid validate tag %q for %v", tag.raw, v.Type())
+	}
+	if len(tag.oneof) > 0 {
+		choice := tag.oneof[f.r.Intn(len(tag.oneof))]
+		switch v.Kind() {
+		case reflect.String:
+			v.SetString(choice)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(choice, 0, 64)
+			if err != nil || v.OverflowInt(n) {
+				invalid()
+			}
+			v.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			n, err := strconv.ParseUint(choice, 0, 64)
+			if err != nil || v.OverflowUint(n) {
+				invalid()
+			}
+			v.SetUint(n)
+		default:
+			return false
+		}
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := uint(v.Type().Bits())
+		lo, hi := int64(-1)<<(bits-1), int64(^uint64(0)>>(65-bits))
+		var err1, err2 error
+		if tag.hasMin {
+			lo, err1 = strconv.ParseInt(tag.min, 0, 64)
+		}
+		if tag.hasMax {
+			hi, err2 = strconv.ParseInt(tag.max, 0, 64)
+		}
+		if err1 != nil || err2 != nil || lo > hi || v.OverflowInt(lo) || v.OverflowInt(hi) {
+			invalid()
+		}
+		v.SetInt(randInt64Range(f.r, lo, hi))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		lo, hi := uint64(0), ^uint64(0)>>(64-uint(v.Type().Bits()))
+		var err1, err2 error
+		if tag.hasMin {
+			lo, err1 = strconv.ParseUint(tag.min, 0, 64)
+		}
+		if tag.hasMax {
+			hi, err2 = strconv.ParseUint(tag.max, 0, 64)
+		}
+		if err1 != nil || err2 != nil || lo > hi || v.OverflowUint(lo) || v.OverflowUint(hi) {
+			invalid()
+		}
+		v.SetUint(randUint64Range(f.r, lo, hi))
+	case reflect.Float32, reflect.Float64:
+		lo, hi := -math.MaxFloat64, math.MaxFloat64
+		if v.Kind() == reflect.Float32 {
+			lo, hi = -math.MaxFloat32, math.MaxFloat32
+		}
+		var err1, err2 error
+		if tag.hasMin {
+			lo, err1 = strconv.ParseFloat(tag.min, 64)
+		}
+		if tag.hasMax {
+			hi, err2 = strconv.ParseFloat(tag.max, 64)
+		}
+		if err1 != nil || err2 != nil || lo > hi {
+			invalid()
+		}
+		// Interpolate without computing hi-lo, which may overflow.
+		x := f.r.Float64()
+		v.SetFloat(lo*(1-x) + hi*x)
+	case reflect.String:
+		lo, hi, ok := validateLen(tag, f.minStringLen, f.maxStringLen)
+		if !ok {
+			invalid()
+		}
+		// Lengths count runes, so the wordlist doesn't apply, and
+		// PrintableStrings and MaxStringBytes may make strings too short.
+		for tries := 0; ; tries++ {
+			str := f.randStringIn(f.r, f.charset, lo, hi)
+			if n := utf8.RuneCountInString(str); lo <= n && n <= hi {
+				v.SetString(str)
+				break
+			}
+			if tries == validateAttempts {
+				fc.fail("no string satisfying validate tag %q found in %d attempts", tag.raw, validateAttempts)
+			}
+		}
+	case reflect.Slice:
+		lo, hi, ok := validateLen(tag, f.minElements, f.maxElements)
+		if !ok {
+			invalid()
+		}
+		n := lo + f.r.Intn(hi-lo+1)
+		fc.elements += n
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			fc.doGreenRunAt(pathSegment{index: i}, v.Index(i), 0)
+		}
+	case reflect.Map:
+		lo, hi, ok := validateLen(tag, f.minElements, f.maxElements)
+		if !ok {
+			invalid()
+		}
+		n := lo + f.r.Intn(hi-lo+1)
+		v.Set(reflect.MakeMap(v.Type()))
+		for tries := 0; v.Len() < n && tries < mapKeyAttempts; {
+			key := reflect.New(v.Type().Key()).Elem()
+			fc.doGreenRunAt(pathSegment{isKey: true}, key, 0)
+			if v.MapIndex(key).IsValid() {
+				tries++
+				continue
+			}
+			tries = 0
+			fc.elements++
+			val := reflect.New(v.Type().Elem()).Elem()
+			fc.doGreenRunAt(pathSegment{key: key}, val, 0)
+			v.SetMapIndex(key, val)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// validateLen returns the bounds on a length given by tag, falling back on
+// min and max where tag doesn't say, and reports whether they are valid.
+func validateLen(tag validateTag, min, max int) (int, int, bool) {
+	var err1, err2 error
+	if tag.hasMin {
+		min, err1 = strconv.Atoi(tag.min)
+		if !tag.hasMax && max < min {
+			max = min
+		}
+	}
+	if tag.hasMax {
+		max, err2 = strconv.Atoi(tag.max)
+		if !tag.hasMin && min > max {
+			min = max
+		}
+	}
+	return min, max, err1 == nil && err2 == nil && 0 <= min && min <= max
+}